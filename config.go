@@ -2,12 +2,19 @@ package loki
 
 import (
 	"context"
+	"io"
+	"os"
+	"slices"
 	"time"
 
+	"github.com/edaniel30/loki-logger-go/internal/labelrules"
 	"github.com/edaniel30/loki-logger-go/internal/transport"
 	"github.com/edaniel30/loki-logger-go/types"
 )
 
+// defaultStackTraceLevels are the levels that capture a stack trace unless configured otherwise.
+var defaultStackTraceLevels = []types.Level{types.LevelError, types.LevelPanic, types.LevelFatal}
+
 // TraceIDExtractor is a function that extracts a trace ID from a context.
 // Return an empty string if no trace ID is present.
 type TraceIDExtractor func(ctx context.Context) string
@@ -17,6 +24,216 @@ type TraceIDExtractor func(ctx context.Context) string
 // the batch is full. It may be called concurrently and must be non-blocking.
 type OnFlushError func(err error)
 
+// RateLimitAction determines what happens to entries that exceed their stream's rate limit.
+type RateLimitAction int
+
+const (
+	// RateLimitDrop discards excess entries from every transport.
+	RateLimitDrop RateLimitAction = iota
+	// RateLimitDowngrade keeps excess entries on the console but does not send them to Loki.
+	RateLimitDowngrade
+)
+
+// String returns the string representation of the RateLimitAction.
+func (a RateLimitAction) String() string {
+	switch a {
+	case RateLimitDrop:
+		return "drop"
+	case RateLimitDowngrade:
+		return "downgrade"
+	default:
+		return "unknown"
+	}
+}
+
+// OnRateLimited is a callback invoked for every entry that exceeds its stream's rate limit.
+// The action reports whether the entry was dropped or downgraded to console only.
+// It may be called concurrently and must be non-blocking.
+type OnRateLimited func(entry *types.Entry, action RateLimitAction)
+
+// CircuitState is the state of the circuit breaker around Loki pushes.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every push through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails pushes fast without contacting Loki.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe push through to test whether Loki recovered.
+	CircuitHalfOpen
+)
+
+// String returns the string representation of the CircuitState.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerPolicy determines what happens to entries rejected while the circuit is open.
+type CircuitBreakerPolicy int
+
+const (
+	// CircuitBreakerDrop discards rejected entries and reports them through OnFlushError.
+	CircuitBreakerDrop CircuitBreakerPolicy = iota
+	// CircuitBreakerBuffer keeps rejected entries in memory, up to CircuitBreakerBufferSize,
+	// and sends them once Loki recovers. The oldest entries are dropped first.
+	CircuitBreakerBuffer
+)
+
+// String returns the string representation of the CircuitBreakerPolicy.
+func (p CircuitBreakerPolicy) String() string {
+	switch p {
+	case CircuitBreakerDrop:
+		return "drop"
+	case CircuitBreakerBuffer:
+		return "buffer"
+	default:
+		return "unknown"
+	}
+}
+
+// OnCircuitStateChange is a callback invoked on every circuit breaker state transition.
+// It is called synchronously from the pushing goroutine once the push released its locks,
+// so it may log through the same logger, and must be non-blocking.
+type OnCircuitStateChange func(from, to CircuitState)
+
+// LabelPolicy determines how labels that break Loki's rules are handled.
+// Loki rejects the whole push with a 400 when a single label is invalid.
+type LabelPolicy int
+
+const (
+	// LabelSanitize rewrites invalid label names (for example "http-status" becomes
+	// "http_status"), truncates long names and values and drops labels beyond MaxLabels.
+	LabelSanitize LabelPolicy = iota
+	// LabelDrop removes every label that breaks a rule.
+	LabelDrop
+	// LabelReject makes New fail on invalid Config.Labels. Labels added later, by
+	// Logger.WithLabels, promoted fields or hooks, are dropped as with LabelDrop;
+	// use Logger.WithCheckedLabels to get an error instead.
+	LabelReject
+)
+
+// String returns the string representation of the LabelPolicy.
+func (p LabelPolicy) String() string {
+	switch p {
+	case LabelSanitize:
+		return "sanitize"
+	case LabelDrop:
+		return "drop"
+	case LabelReject:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+// LineSizePolicy determines how log lines longer than MaxLineSize are shortened.
+// Loki rejects the whole push with a 400 when a single line exceeds its max_line_size.
+type LineSizePolicy int
+
+const (
+	// LineTruncate cuts the end of the message, then the largest field values if that
+	// is not enough, and adds a "truncated": true field.
+	LineTruncate LineSizePolicy = iota
+	// LineTrimFields cuts the largest field values first, such as stack traces or request
+	// bodies, then the message, and adds a "truncated": true field.
+	LineTrimFields
+	// LineSplit spreads the message over several lines sharing a "split_id" field, numbered
+	// by "split_part" out of "split_parts". Fields are only kept on the first line.
+	LineSplit
+)
+
+// String returns the string representation of the LineSizePolicy.
+func (p LineSizePolicy) String() string {
+	switch p {
+	case LineTruncate:
+		return "truncate"
+	case LineTrimFields:
+		return "trim-fields"
+	case LineSplit:
+		return "split"
+	default:
+		return "unknown"
+	}
+}
+
+// ConsoleFormat is the output format of the console transport.
+type ConsoleFormat int
+
+const (
+	// ConsoleHuman writes colored, human-readable lines.
+	ConsoleHuman ConsoleFormat = iota
+	// ConsoleJSON writes one JSON object per line with the message and fields of the Loki
+	// log line, plus "timestamp", "labels" and "metadata", for Promtail or Alloy to scrape.
+	ConsoleJSON
+	// ConsoleLogfmt writes logfmt lines: time, level and msg, then labels, fields and
+	// structured metadata. Values are quoted and escaped when needed.
+	ConsoleLogfmt
+)
+
+// String returns the string representation of the ConsoleFormat.
+func (f ConsoleFormat) String() string {
+	switch f {
+	case ConsoleHuman:
+		return "human"
+	case ConsoleJSON:
+		return "json"
+	case ConsoleLogfmt:
+		return "logfmt"
+	default:
+		return "unknown"
+	}
+}
+
+// ConsoleColor determines whether the human console format uses ANSI colors.
+type ConsoleColor int
+
+const (
+	// ColorAuto uses colors when the console writer is a terminal. The NO_COLOR
+	// environment variable disables them and FORCE_COLOR enables them.
+	ColorAuto ConsoleColor = iota
+	// ColorAlways always uses colors.
+	ColorAlways
+	// ColorNever never uses colors.
+	ColorNever
+)
+
+// String returns the string representation of the ConsoleColor.
+func (c ConsoleColor) String() string {
+	switch c {
+	case ColorAuto:
+		return "auto"
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	default:
+		return "unknown"
+	}
+}
+
+// OnLabelOverflow is a callback invoked when a promoted label exceeds MaxLabelValues
+// and its value is replaced with LabelOverflowValue. It may be called concurrently
+// and must be non-blocking.
+type OnLabelOverflow func(label, value string)
+
+// OnRejected is a callback invoked with the entries Loki rejected with a 400 status, such as
+// out-of-order entries, and the error Loki returned. Rejected entries are isolated from their
+// batch, whose other entries are still pushed. It is called from the flushing goroutine and
+// must be non-blocking.
+type OnRejected func(entries []*types.Entry, err error)
+
+// ExitFunc terminates the process after a Fatal log. It receives the exit code.
+type ExitFunc func(code int)
+
 // Config holds the logger configuration.
 // Use DefaultConfig() to get sensible defaults, then customize with Option functions.
 type Config struct {
@@ -28,25 +245,140 @@ type Config struct {
 	// If the caller already includes "trace_id" in fields, it is not overwritten.
 	TraceIDExtractor TraceIDExtractor
 
+	// TraceContextExtractor is an optional function to extract W3C trace context from the
+	// context. If set, "trace_id", "span_id" and "trace_flags" are added to every log entry,
+	// enabling Grafana's Loki-to-Tempo derived fields. Fields provided by the caller (or
+	// by TraceIDExtractor) are not overwritten. See W3CTraceContext.
+	TraceContextExtractor TraceContextExtractor
+
 	// OnFlushError is an optional callback invoked whenever a flush to Loki fails,
 	// including both background periodic flushes and synchronous flushes triggered
 	// by Write when the batch is full. If nil, flush errors are silently discarded.
 	OnFlushError OnFlushError
 
+	// Entries rejected by Loki with a 400 status are isolated from their batch by pushing it
+	// again in smaller requests, so that the rest of the batch is not lost. Rejected entries
+	// are written to DeadLetter and passed to OnRejected. If neither is set, they are
+	// reported through OnFlushError.
+	DeadLetter DeadLetter // Optional destination for rejected entries, see DeadLetterWriter
+	OnRejected OnRejected // Optional callback invoked with rejected entries
+
+	// Rate limiting per Loki stream (label set).
+	// Limits are enforced with a token bucket holding one second worth of tokens.
+	// A zero value disables the corresponding limit.
+	RateLimitLines  float64         // Maximum lines per second per stream (default: 0, disabled)
+	RateLimitBytes  float64         // Maximum approximate bytes per second per stream (default: 0, disabled)
+	RateLimitAction RateLimitAction // What to do with excess entries (default: RateLimitDrop)
+	OnRateLimited   OnRateLimited   // Optional callback invoked for every rate-limited entry
+
+	// Circuit breaker around Loki pushes. After CircuitBreakerThreshold consecutive
+	// failed push attempts, pushes fail fast for CircuitBreakerTimeout, then a single
+	// probe push decides whether to close the circuit again.
+	CircuitBreakerThreshold  int                  // Consecutive failed attempts that open the circuit (default: 0, disabled)
+	CircuitBreakerTimeout    time.Duration        // How long the circuit stays open before a probe (default: 30s)
+	CircuitBreakerPolicy     CircuitBreakerPolicy // What to do with entries while the circuit is open (default: CircuitBreakerDrop)
+	CircuitBreakerBufferSize int                  // Max entries kept with CircuitBreakerBuffer (default: 10000)
+	OnCircuitStateChange     OnCircuitStateChange // Optional callback invoked on every state transition
+
+	// Hooks run in order on every entry before it is written to transports.
+	// They can enrich, rewrite or drop entries. See Hook.
+	Hooks []Hook
+
+	// Redaction rules applied to the message and fields of every entry before it
+	// reaches any transport. See RedactCredentials, RedactEmails and the other presets.
+	Redaction []RedactionRule
+
+	// DedupeWindow enables duplicate-message suppression when greater than 0.
+	// Entries with the same level, message and labels seen within the window are
+	// collapsed: the first one is written immediately and the rest are reported as a
+	// single entry with "repeat_count", "first_timestamp" and "last_timestamp" fields
+	// when the window closes or on Flush (default: 0, disabled).
+	DedupeWindow time.Duration
+
+	// Health reporting, see Logger.Health.
+	HealthCheckReady       bool // Also query Loki's /ready endpoint in Logger.Health (default: false)
+	HealthFailureThreshold int  // Consecutive push failures after which a transport is reported down; 0 only degrades (default: 3)
+
+	// Label validation per Loki's rules: names must match [a-zA-Z_][a-zA-Z0-9_]* and must
+	// not start with the reserved "__" prefix. Limits match Loki's defaults; 0 disables a limit.
+	// System labels (app, level, version, environment) count towards MaxLabels and are never dropped.
+	LabelPolicy         LabelPolicy // How invalid labels are handled (default: LabelSanitize)
+	MaxLabels           int         // Maximum labels per entry (default: 15)
+	MaxLabelNameLength  int         // Maximum label name length in bytes (default: 1024)
+	MaxLabelValueLength int         // Maximum label value length in bytes (default: 2048)
+
+	// Field promotion to labels. Fields listed in PromotedFields are copied to labels
+	// of the same name on every entry that has them. System labels (app, level,
+	// version, environment) cannot be overridden. Each promoted label may take at most
+	// MaxLabelValues distinct values; later values are replaced with LabelOverflowValue
+	// so a runaway field cannot create unbounded streams.
+	PromotedFields  []string        // Field keys promoted to labels (default: none)
+	MaxLabelValues  int             // Distinct values allowed per promoted label (default: 50)
+	OnLabelOverflow OnLabelOverflow // Optional callback invoked for every overflowing value
+
+	// MetadataKeys lists field keys sent to Loki as structured metadata instead of in the
+	// JSON log line. Structured metadata is attached to each line without being indexed,
+	// which suits high-cardinality values such as "trace_id" or "user_id". Requires Loki 3.x.
+	MetadataKeys []string
+
+	// Line size limit, enforced when formatting the JSON line sent to Loki. Match MaxLineSize
+	// to the max_line_size of your Loki tenant. The console output is not affected.
+	MaxLineSize    int            // Maximum log line size in bytes, 0 disables the limit (default: 0)
+	LineSizePolicy LineSizePolicy // How longer lines are shortened (default: LineTruncate)
+
+	// StrictTimestamps makes the timestamps of every stream strictly increasing, for Loki
+	// versions or tenants that reject out-of-order writes. Entries are always sorted by
+	// timestamp within a push; with StrictTimestamps an entry whose timestamp is not after
+	// the previous one of its stream, in the same push or one Loki accepted earlier, is
+	// moved forward to 1ns after it, and pushes are serialized (default: false).
+	StrictTimestamps bool
+
+	// Accepted timestamp window for entries logged with Logger.LogAt, matching Loki's
+	// reject_old_samples_max_age and creation_grace_period limits. Entries outside the
+	// window are dropped and LogAt returns a TimestampError. 0 disables a bound.
+	RejectOldSamplesMaxAge time.Duration // Maximum age of an entry (default: 168h)
+	CreationGracePeriod    time.Duration // Maximum time an entry may be in the future (default: 10m)
+
 	// Loki connection
 	LokiHost     string // Loki server URL, e.g., "http://localhost:3100" (required if not OnlyConsole)
 	LokiUsername string // Username for basic auth (optional)
 	LokiPassword string // Password for basic auth (optional)
 
+	// ExitFunc is called with code 1 after a Fatal entry has been logged and all
+	// transports have been flushed (bounded by Timeout). Replace it to run cleanup
+	// or to stub process termination in tests. If nil, os.Exit is used.
+	ExitFunc ExitFunc
+
+	// RepanicOnRecover makes Logger.Recover and Logger.Go log recovered panics at
+	// LevelFatal and panic again after flushing, instead of logging them at
+	// LevelError and swallowing them (default: false).
+	RepanicOnRecover bool
+
+	// StackTraceLevels lists the levels that automatically capture a structured
+	// "stacktrace" field (default: types.LevelError, types.LevelPanic and types.LevelFatal).
+	// A nil value means the default levels; set it to an empty slice to disable stack traces.
+	StackTraceLevels []types.Level
+
 	// Logging behavior
 	LogLevel    types.Level  // Minimum level to log (default: types.LevelInfo)
 	Labels      types.Labels // Default labels attached to all log entries
 	OnlyConsole bool         // Only log to console, skip Loki (default: false)
 
+	// Console output. Warn entries and above go to ConsoleErrorWriter, so errors can be
+	// sent to stderr. The time zone also applies to the JSON and logfmt formats, which
+	// always use RFC 3339 timestamps.
+	ConsoleFormat       ConsoleFormat  // Output format (default: ConsoleHuman)
+	ConsoleWriter       io.Writer      // Writer for all entries, nil writes to os.Stdout (default: nil)
+	ConsoleErrorWriter  io.Writer      // Writer for Warn entries and above (default: ConsoleWriter)
+	ConsoleColor        ConsoleColor   // Colors of the human format (default: ColorAuto)
+	ConsoleTimeLayout   string         // Timestamp layout of the human format (default: "2006-01-02 15:04:05")
+	ConsoleTimeLocation *time.Location // Time zone of console timestamps (default: local time)
+
 	// Performance settings
 	BatchSize     int           // Number of logs to accumulate before sending to Loki (default: 100)
+	MaxBatchBytes int           // Approximate batch size in bytes that triggers a flush; larger pushes are split, 0 disables (default: 0)
 	FlushInterval time.Duration // How often to flush logs to Loki regardless of batch size (default: 5s)
-	MaxRetries    int           // Number of times to retry failed requests to Loki (default: 3)
+	MaxRetries    int           // Retries of pushes failing with a network error, 5xx or 429; other 4xx are not retried (default: 3)
 	Timeout       time.Duration // Timeout for operations (connect, write, flush, shutdown) (default: 10s)
 
 	AppVersion string // Version of the application (default: "1.0.0")
@@ -64,10 +396,32 @@ type Config struct {
 //   - LogLevel: LevelInfo
 //   - Labels: empty map
 //   - OnlyConsole: false (logs to both console and Loki)
+//   - ConsoleFormat: ConsoleHuman
+//   - ConsoleWriter: nil, writing to os.Stdout (ConsoleErrorWriter: same writer)
+//   - ConsoleColor: ColorAuto (colors on terminals, honoring NO_COLOR and FORCE_COLOR)
+//   - ConsoleTimeLayout: "2006-01-02 15:04:05" in local time
 //   - BatchSize: 100
+//   - MaxBatchBytes: 0 (only BatchSize triggers a flush)
 //   - FlushInterval: 5 seconds
 //   - MaxRetries: 3
 //   - Timeout: 10 seconds
+//   - Rate limiting: disabled
+//   - DedupeWindow: 0 (disabled)
+//   - StackTraceLevels: LevelError, LevelPanic, LevelFatal
+//   - ExitFunc: os.Exit
+//   - RepanicOnRecover: false
+//   - Hooks: none
+//   - Redaction: none
+//   - Circuit breaker: disabled (30s open timeout, drop policy, 10000 buffered entries once enabled)
+//   - LabelPolicy: LabelSanitize (MaxLabels: 15, MaxLabelNameLength: 1024, MaxLabelValueLength: 2048)
+//   - PromotedFields: none (MaxLabelValues: 50)
+//   - MetadataKeys: none
+//   - MaxLineSize: 0, no limit (LineSizePolicy: LineTruncate once set)
+//   - StrictTimestamps: false
+//   - RejectOldSamplesMaxAge: 168h, CreationGracePeriod: 10m
+//   - DeadLetter, OnRejected: none (rejected entries reported through OnFlushError)
+//   - HealthCheckReady: false
+//   - HealthFailureThreshold: 3
 //
 // Example:
 //
@@ -78,18 +432,39 @@ type Config struct {
 //	)
 func DefaultConfig() *Config {
 	return &Config{
-		AppName:           "app",
-		AppVersion:        "1.0.0",
-		AppEnv:            "local",
-		LokiHost:    "http://localhost:3100",
-		LogLevel:    types.LevelInfo,
-		Labels:      make(types.Labels),
-		OnlyConsole: false,
-		BatchSize:         100,
-		FlushInterval:     5 * time.Second,
-		MaxRetries:        3,
-		Timeout:           10 * time.Second,
-		TraceIDExtractor:  nil,
+		AppName:                  "app",
+		AppVersion:               "1.0.0",
+		AppEnv:                   "local",
+		LokiHost:                 "http://localhost:3100",
+		LogLevel:                 types.LevelInfo,
+		Labels:                   make(types.Labels),
+		OnlyConsole:              false,
+		ConsoleFormat:            ConsoleHuman,
+		ConsoleColor:             ColorAuto,
+		ConsoleTimeLayout:        transport.DefaultTimeLayout,
+		BatchSize:                100,
+		MaxBatchBytes:            0,
+		FlushInterval:            5 * time.Second,
+		MaxRetries:               3,
+		Timeout:                  10 * time.Second,
+		TraceIDExtractor:         nil,
+		TraceContextExtractor:    nil,
+		RateLimitAction:          RateLimitDrop,
+		StackTraceLevels:         slices.Clone(defaultStackTraceLevels),
+		ExitFunc:                 os.Exit,
+		HealthFailureThreshold:   3,
+		CircuitBreakerTimeout:    30 * time.Second,
+		CircuitBreakerPolicy:     CircuitBreakerDrop,
+		CircuitBreakerBufferSize: 10000,
+		MaxLabelValues:           50,
+		LabelPolicy:              LabelSanitize,
+		MaxLabels:                15,
+		MaxLabelNameLength:       1024,
+		MaxLabelValueLength:      2048,
+		MaxLineSize:              0,
+		LineSizePolicy:           LineTruncate,
+		RejectOldSamplesMaxAge:   168 * time.Hour,
+		CreationGracePeriod:      10 * time.Minute,
 	}
 }
 
@@ -207,6 +582,20 @@ func WithBatchSize(size int) Option {
 	}
 }
 
+// WithMaxBatchBytes sets the approximate batch size in bytes that triggers a flush, and the
+// maximum size of a push request: larger pushes are split into several requests. Keep it
+// below Loki's grpc_server_max_recv_msg_size, e.g. 4MB with Loki's defaults.
+// Pass 0 to only flush on BatchSize. Default is 0, which keeps pushes unsplit.
+//
+// Example:
+//
+//	loki.WithMaxBatchBytes(1 << 20) // 1MB
+func WithMaxBatchBytes(size int) Option {
+	return func(c *Config) {
+		c.MaxBatchBytes = size
+	}
+}
+
 // WithFlushInterval sets how often to flush logs to Loki regardless of batch size.
 // This ensures logs are sent even if the batch isn't full.
 // Default is 5 seconds.
@@ -233,6 +622,20 @@ func WithTraceIDExtractor(fn TraceIDExtractor) Option {
 	}
 }
 
+// WithTraceContextExtractor sets a function that extracts W3C trace context from the context on
+// every log call. The result populates the "trace_id", "span_id" and "trace_flags" fields.
+// Fields the caller already provides are not overwritten.
+//
+// Example:
+//
+//	loki.WithTraceContextExtractor(loki.W3CTraceContext) // reads loki.ContextWithTraceParent
+//	loki.WithTraceContextExtractor(lokiotel.TraceContext) // reads the OpenTelemetry span
+func WithTraceContextExtractor(fn TraceContextExtractor) Option {
+	return func(c *Config) {
+		c.TraceContextExtractor = fn
+	}
+}
+
 // WithOnFlushError sets a callback that is invoked whenever a flush to Loki fails,
 // including background periodic flushes and synchronous flushes triggered by Write.
 // The callback may be called concurrently and must not block.
@@ -248,6 +651,410 @@ func WithOnFlushError(fn OnFlushError) Option {
 	}
 }
 
+// WithRateLimit limits how many lines and bytes per second each Loki stream may produce.
+// A stream is identified by its full label set, so a noisy component with its own labels
+// cannot exhaust the limits of the rest of the application. Pass 0 to disable a limit.
+//
+// Example:
+//
+//	loki.WithRateLimit(100, 64*1024) // 100 lines/s and 64KB/s per stream
+func WithRateLimit(linesPerSecond, bytesPerSecond float64) Option {
+	return func(c *Config) {
+		c.RateLimitLines = linesPerSecond
+		c.RateLimitBytes = bytesPerSecond
+	}
+}
+
+// WithRateLimitAction sets what happens to entries that exceed the stream rate limit.
+// Default is RateLimitDrop.
+//
+// Example:
+//
+//	loki.WithRateLimitAction(loki.RateLimitDowngrade) // keep excess logs on console only
+func WithRateLimitAction(action RateLimitAction) Option {
+	return func(c *Config) {
+		c.RateLimitAction = action
+	}
+}
+
+// WithOnRateLimited sets a callback that is invoked for every entry exceeding the stream rate limit.
+// The callback may be called concurrently and must not block.
+//
+// Example:
+//
+//	var dropped atomic.Int64
+//	loki.WithOnRateLimited(func(entry *types.Entry, action loki.RateLimitAction) {
+//		dropped.Add(1)
+//	})
+func WithOnRateLimited(fn OnRateLimited) Option {
+	return func(c *Config) {
+		c.OnRateLimited = fn
+	}
+}
+
+// WithStackTraceLevels sets the levels that automatically capture a structured
+// "stacktrace" field. Call it without arguments to disable stack traces.
+// Default is LevelError, LevelPanic and LevelFatal.
+//
+// Example:
+//
+//	loki.WithStackTraceLevels(types.LevelFatal) // only fatal logs
+//	loki.WithStackTraceLevels()                 // never
+func WithStackTraceLevels(levels ...types.Level) Option {
+	return func(c *Config) {
+		// Never nil, so that calling it without arguments disables stack traces
+		c.StackTraceLevels = append([]types.Level{}, levels...)
+	}
+}
+
+// WithExitFunc sets the function that terminates the process after a Fatal log.
+// The logger flushes every transport before calling it. Default is os.Exit.
+//
+// Example:
+//
+//	loki.WithExitFunc(func(code int) {
+//		shutdownGracefully()
+//		os.Exit(code)
+//	})
+func WithExitFunc(fn ExitFunc) Option {
+	return func(c *Config) {
+		c.ExitFunc = fn
+	}
+}
+
+// WithRepanicOnRecover controls whether panics caught by Logger.Recover and Logger.Go
+// are re-raised after being logged and flushed. When enabled they are logged at
+// LevelFatal, otherwise at LevelError. Default is false.
+//
+// Example:
+//
+//	loki.WithRepanicOnRecover(true) // log, flush, then crash as usual
+func WithRepanicOnRecover(enabled bool) Option {
+	return func(c *Config) {
+		c.RepanicOnRecover = enabled
+	}
+}
+
+// WithHooks registers hooks that run on every entry before it is written to transports.
+// Hooks run in registration order and are cumulative across calls.
+//
+// Example:
+//
+//	loki.WithHooks(
+//		loki.HostnameHook(),
+//		loki.HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+//			return entry.Fields["path"] != "/healthz" // drop health check noise
+//		}),
+//	)
+func WithHooks(hooks ...Hook) Option {
+	return func(c *Config) {
+		c.Hooks = append(c.Hooks, hooks...)
+	}
+}
+
+// WithRedaction adds rules that redact sensitive data from messages and fields
+// before entries reach any transport. Rules are cumulative across calls.
+//
+// Example:
+//
+//	loki.WithRedaction(
+//		loki.RedactCredentials(),
+//		loki.RedactEmails(),
+//		loki.RedactionRule{Keys: []string{"ssn"}, Strategy: loki.RedactHash},
+//	)
+func WithRedaction(rules ...RedactionRule) Option {
+	return func(c *Config) {
+		c.Redaction = append(c.Redaction, rules...)
+	}
+}
+
+// WithDedupe enables duplicate-message suppression within the given window.
+// Useful when a failing dependency makes the same error repeat thousands of times per second.
+// The first occurrence is written immediately; repeats are collapsed into a single entry
+// carrying a "repeat_count" field, emitted when the window closes or on Flush.
+//
+// Example:
+//
+//	loki.WithDedupe(10 * time.Second)
+func WithDedupe(window time.Duration) Option {
+	return func(c *Config) {
+		c.DedupeWindow = window
+	}
+}
+
+// WithCircuitBreaker opens a circuit breaker around Loki pushes after the given number
+// of consecutive failed attempts. While open, pushes fail fast instead of waiting through
+// retries and timeouts; after openTimeout a single probe push is allowed through.
+// Pass 0 as threshold to disable it.
+//
+// Example:
+//
+//	loki.WithCircuitBreaker(5, 30*time.Second)
+func WithCircuitBreaker(threshold int, openTimeout time.Duration) Option {
+	return func(c *Config) {
+		c.CircuitBreakerThreshold = threshold
+		c.CircuitBreakerTimeout = openTimeout
+	}
+}
+
+// WithCircuitBreakerPolicy sets what happens to entries while the circuit is open.
+// With CircuitBreakerBuffer, up to bufferSize entries are kept in memory and sent once
+// Loki recovers; bufferSize is ignored with CircuitBreakerDrop. Default is CircuitBreakerDrop.
+//
+// Example:
+//
+//	loki.WithCircuitBreakerPolicy(loki.CircuitBreakerBuffer, 50000)
+func WithCircuitBreakerPolicy(policy CircuitBreakerPolicy, bufferSize int) Option {
+	return func(c *Config) {
+		c.CircuitBreakerPolicy = policy
+		if policy == CircuitBreakerBuffer {
+			c.CircuitBreakerBufferSize = bufferSize
+		}
+	}
+}
+
+// WithOnCircuitStateChange sets a callback that is invoked on every circuit breaker state transition.
+// The callback is called synchronously and must not block.
+//
+// Example:
+//
+//	loki.WithOnCircuitStateChange(func(from, to loki.CircuitState) {
+//		fmt.Fprintf(os.Stderr, "loki circuit %s -> %s\n", from, to)
+//	})
+func WithOnCircuitStateChange(fn OnCircuitStateChange) Option {
+	return func(c *Config) {
+		c.OnCircuitStateChange = fn
+	}
+}
+
+// WithLabelPolicy sets how labels that break Loki's naming rules or limits are handled.
+// Default is LabelSanitize.
+//
+// Example:
+//
+//	loki.WithLabelPolicy(loki.LabelReject) // fail fast on invalid Config.Labels
+func WithLabelPolicy(policy LabelPolicy) Option {
+	return func(c *Config) {
+		c.LabelPolicy = policy
+	}
+}
+
+// WithLabelLimits sets the maximum number of labels per entry and the maximum label
+// name and value lengths in bytes. Match them to the limits of your Loki tenant.
+// Pass 0 to disable a limit. Defaults are 15, 1024 and 2048, as in Loki.
+//
+// Example:
+//
+//	loki.WithLabelLimits(30, 1024, 2048)
+func WithLabelLimits(maxLabels, maxNameLength, maxValueLength int) Option {
+	return func(c *Config) {
+		c.MaxLabels = maxLabels
+		c.MaxLabelNameLength = maxNameLength
+		c.MaxLabelValueLength = maxValueLength
+	}
+}
+
+// WithPromotedFields copies the given fields to labels of the same name on every entry
+// that has them. Keep promoted fields low cardinality: each label is capped at
+// MaxLabelValues distinct values. Keys are cumulative across calls.
+//
+// Example:
+//
+//	loki.WithPromotedFields("http_status_class", "tenant_tier")
+func WithPromotedFields(keys ...string) Option {
+	return func(c *Config) {
+		c.PromotedFields = append(c.PromotedFields, keys...)
+	}
+}
+
+// WithMaxLabelValues caps the number of distinct values of each promoted label.
+// Values beyond the cap are replaced with LabelOverflowValue and a warning is logged
+// once per label. Default is 50.
+//
+// Example:
+//
+//	loki.WithMaxLabelValues(20)
+func WithMaxLabelValues(max int) Option {
+	return func(c *Config) {
+		c.MaxLabelValues = max
+	}
+}
+
+// WithOnLabelOverflow sets a callback that is invoked for every promoted label value
+// replaced with LabelOverflowValue. The callback may be called concurrently and must not block.
+//
+// Example:
+//
+//	loki.WithOnLabelOverflow(func(label, value string) {
+//		overflows.WithLabelValues(label).Inc()
+//	})
+func WithOnLabelOverflow(fn OnLabelOverflow) Option {
+	return func(c *Config) {
+		c.OnLabelOverflow = fn
+	}
+}
+
+// WithMetadataKeys sends the given field keys to Loki as structured metadata instead of
+// in the JSON log line. Keys are cumulative across calls. Requires Loki 3.x.
+//
+// Example:
+//
+//	loki.WithMetadataKeys("trace_id", "span_id", "user_id")
+func WithMetadataKeys(keys ...string) Option {
+	return func(c *Config) {
+		c.MetadataKeys = append(c.MetadataKeys, keys...)
+	}
+}
+
+// WithMaxLineSize sets the maximum log line size in bytes sent to Loki and how longer
+// lines are shortened. Match it to the max_line_size of your Loki tenant, 256KB by default.
+// Pass 0 to disable the limit. Default is 0, which sends lines unchanged.
+//
+// Example:
+//
+//	loki.WithMaxLineSize(64*1024, loki.LineSplit)
+func WithMaxLineSize(size int, policy LineSizePolicy) Option {
+	return func(c *Config) {
+		c.MaxLineSize = size
+		c.LineSizePolicy = policy
+	}
+}
+
+// WithHealthCheckReady makes Logger.Health actively query Loki's /ready endpoint
+// in addition to reporting the outcome of recent pushes. Default is false.
+//
+// Example:
+//
+//	loki.WithHealthCheckReady(true)
+func WithHealthCheckReady(enabled bool) Option {
+	return func(c *Config) {
+		c.HealthCheckReady = enabled
+	}
+}
+
+// WithHealthFailureThreshold sets how many consecutive push failures mark a transport
+// as down in Logger.Health. Fewer failures report it as degraded. Pass 0 to never
+// report a transport down because of push failures alone. Default is 3.
+//
+// Example:
+//
+//	loki.WithHealthFailureThreshold(5)
+func WithHealthFailureThreshold(failures int) Option {
+	return func(c *Config) {
+		c.HealthFailureThreshold = failures
+	}
+}
+
+// WithStrictTimestamps makes timestamps strictly increasing per stream, moving colliding or
+// out-of-order timestamps forward by a few nanoseconds. Enable it when Loki rejects
+// out-of-order writes (unordered_writes disabled or Loki before 2.4). Default is false.
+//
+// Example:
+//
+//	loki.WithStrictTimestamps(true)
+func WithStrictTimestamps(enabled bool) Option {
+	return func(c *Config) {
+		c.StrictTimestamps = enabled
+	}
+}
+
+// WithTimestampWindow sets the timestamp window accepted by Logger.LogAt: entries older than
+// maxAge or more than gracePeriod in the future are dropped. Match them to the
+// reject_old_samples_max_age and creation_grace_period limits of your Loki tenant.
+// Pass 0 to disable a bound. Defaults are 168h and 10m, as in Loki.
+//
+// Example:
+//
+//	loki.WithTimestampWindow(30*24*time.Hour, 10*time.Minute) // tenant accepting a month of backfill
+func WithTimestampWindow(maxAge, gracePeriod time.Duration) Option {
+	return func(c *Config) {
+		c.RejectOldSamplesMaxAge = maxAge
+		c.CreationGracePeriod = gracePeriod
+	}
+}
+
+// WithDeadLetter sets where entries rejected by Loki are written instead of being lost.
+// The rest of their batch is still pushed.
+//
+// Example:
+//
+//	loki.WithDeadLetter(loki.DeadLetterWriter(os.Stderr))
+func WithDeadLetter(deadLetter DeadLetter) Option {
+	return func(c *Config) {
+		c.DeadLetter = deadLetter
+	}
+}
+
+// WithOnRejected sets a callback that is invoked with the entries Loki rejected with a
+// 400 status and the error it returned. The callback must not block.
+//
+// Example:
+//
+//	loki.WithOnRejected(func(entries []*types.Entry, err error) {
+//		rejected.Add(float64(len(entries)))
+//	})
+func WithOnRejected(fn OnRejected) Option {
+	return func(c *Config) {
+		c.OnRejected = fn
+	}
+}
+
+// WithConsoleFormat sets the output format of the console transport.
+// Default is ConsoleHuman; use ConsoleJSON or ConsoleLogfmt when a log collector
+// scrapes stdout, for example in containers.
+//
+// Example:
+//
+//	loki.WithConsoleFormat(loki.ConsoleJSON)
+func WithConsoleFormat(format ConsoleFormat) Option {
+	return func(c *Config) {
+		c.ConsoleFormat = format
+	}
+}
+
+// WithConsoleWriter sets the writer of the console transport, and optionally a separate
+// writer for Warn entries and above. A nil w writes to os.Stdout and a nil errorWriter
+// sends every entry to w. Default is os.Stdout for every entry.
+//
+// Example:
+//
+//	loki.WithConsoleWriter(os.Stdout, os.Stderr) // warnings and errors to stderr
+func WithConsoleWriter(w, errorWriter io.Writer) Option {
+	return func(c *Config) {
+		c.ConsoleWriter = w
+		c.ConsoleErrorWriter = errorWriter
+	}
+}
+
+// WithConsoleColor sets whether the human console format uses ANSI colors.
+// Default is ColorAuto: colors only when the writer is a terminal, unless the NO_COLOR
+// or FORCE_COLOR environment variable is set.
+//
+// Example:
+//
+//	loki.WithConsoleColor(loki.ColorNever)
+func WithConsoleColor(mode ConsoleColor) Option {
+	return func(c *Config) {
+		c.ConsoleColor = mode
+	}
+}
+
+// WithConsoleTime sets the timestamp layout of the human console format and the time zone
+// of console timestamps. An empty layout keeps the default "2006-01-02 15:04:05" and a nil
+// location keeps local time.
+//
+// Example:
+//
+//	loki.WithConsoleTime(time.RFC3339Nano, time.UTC)
+func WithConsoleTime(layout string, location *time.Location) Option {
+	return func(c *Config) {
+		if layout != "" {
+			c.ConsoleTimeLayout = layout
+		}
+		c.ConsoleTimeLocation = location
+	}
+}
+
 // WithOnFlushErrorConsole sets a flush-error callback that writes the error to the console
 // transport using the same format as the rest of the logs. This is the recommended option
 // to surface Loki connectivity problems (wrong host, network unreachable) without any
@@ -257,9 +1064,9 @@ func WithOnFlushError(fn OnFlushError) Option {
 //
 //	loki.WithOnFlushErrorConsole()
 func WithOnFlushErrorConsole() Option {
-	console := transport.NewConsoleTransport()
 	return func(c *Config) {
 		c.OnFlushError = func(err error) {
+			console := transport.NewConsoleTransportWithConfig(c.consoleTransportConfig())
 			entry := &types.Entry{
 				Level:     types.LevelError,
 				Message:   "loki flush error: " + err.Error(),
@@ -277,6 +1084,43 @@ func WithOnFlushErrorConsole() Option {
 	}
 }
 
+// consoleTransportConfig returns the settings of the console transport.
+func (c *Config) consoleTransportConfig() transport.ConsoleTransportConfig {
+	return transport.ConsoleTransportConfig{
+		Format:       transport.ConsoleFormat(c.ConsoleFormat),
+		Writer:       c.ConsoleWriter,
+		ErrorWriter:  c.ConsoleErrorWriter,
+		Color:        transport.ColorMode(c.ConsoleColor),
+		TimeLayout:   c.ConsoleTimeLayout,
+		TimeLocation: c.ConsoleTimeLocation,
+	}
+}
+
+// capturesStackTrace reports whether entries at the given level capture a stack trace.
+// A nil StackTraceLevels means the default levels, so configs not built with DefaultConfig
+// keep stack traces; an empty one disables them.
+func (c *Config) capturesStackTrace(level types.Level) bool {
+	if c.StackTraceLevels == nil {
+		return slices.Contains(defaultStackTraceLevels, level)
+	}
+	return slices.Contains(c.StackTraceLevels, level)
+}
+
+// labelRules returns the label rules enforced on every entry.
+// LabelReject can only fail at configuration time, so entries are handled as with LabelDrop.
+func (c *Config) labelRules() labelrules.Rules {
+	mode := labelrules.Sanitize
+	if c.LabelPolicy != LabelSanitize {
+		mode = labelrules.Drop
+	}
+	return labelrules.Rules{
+		Mode:           mode,
+		MaxLabels:      c.MaxLabels,
+		MaxNameLength:  c.MaxLabelNameLength,
+		MaxValueLength: c.MaxLabelValueLength,
+	}
+}
+
 // validate checks if the configuration is valid.
 // Returns a ConfigError if any required field is missing or invalid.
 func (c *Config) validate() error {
@@ -292,6 +1136,10 @@ func (c *Config) validate() error {
 		return newConfigFieldError("BatchSize", "must be greater than 0")
 	}
 
+	if c.MaxBatchBytes < 0 {
+		return newConfigFieldError("MaxBatchBytes", "cannot be negative")
+	}
+
 	if c.FlushInterval <= 0 {
 		return newConfigFieldError("FlushInterval", "must be greater than 0")
 	}
@@ -304,5 +1152,87 @@ func (c *Config) validate() error {
 		return newConfigFieldError("Timeout", "must be greater than 0")
 	}
 
+	if c.DedupeWindow < 0 {
+		return newConfigFieldError("DedupeWindow", "cannot be negative")
+	}
+
+	if c.RateLimitLines < 0 {
+		return newConfigFieldError("RateLimitLines", "cannot be negative")
+	}
+
+	if c.RateLimitBytes < 0 {
+		return newConfigFieldError("RateLimitBytes", "cannot be negative")
+	}
+
+	if c.RateLimitAction < RateLimitDrop || c.RateLimitAction > RateLimitDowngrade {
+		return newConfigFieldError("RateLimitAction", "is not a valid action")
+	}
+
+	if c.CircuitBreakerThreshold < 0 {
+		return newConfigFieldError("CircuitBreakerThreshold", "cannot be negative")
+	}
+
+	if c.CircuitBreakerPolicy < CircuitBreakerDrop || c.CircuitBreakerPolicy > CircuitBreakerBuffer {
+		return newConfigFieldError("CircuitBreakerPolicy", "is not a valid policy")
+	}
+
+	if c.CircuitBreakerThreshold > 0 && c.CircuitBreakerTimeout <= 0 {
+		return newConfigFieldError("CircuitBreakerTimeout", "must be greater than 0 when the circuit breaker is enabled")
+	}
+
+	if c.CircuitBreakerThreshold > 0 && c.CircuitBreakerPolicy == CircuitBreakerBuffer && c.CircuitBreakerBufferSize <= 0 {
+		return newConfigFieldError("CircuitBreakerBufferSize", "must be greater than 0 with CircuitBreakerBuffer")
+	}
+
+	if c.MaxLabels < 0 {
+		return newConfigFieldError("MaxLabels", "cannot be negative")
+	}
+
+	if c.MaxLabelNameLength < 0 {
+		return newConfigFieldError("MaxLabelNameLength", "cannot be negative")
+	}
+
+	if c.MaxLabelValueLength < 0 {
+		return newConfigFieldError("MaxLabelValueLength", "cannot be negative")
+	}
+
+	if c.LabelPolicy == LabelReject {
+		if err := c.labelRules().CheckAll(c.Labels, systemLabels...); err != nil {
+			return newConfigFieldError("Labels", err.Error())
+		}
+	}
+
+	if len(c.PromotedFields) > 0 && c.MaxLabelValues <= 0 {
+		return newConfigFieldError("MaxLabelValues", "must be greater than 0 when PromotedFields is set")
+	}
+
+	if c.ConsoleFormat < ConsoleHuman || c.ConsoleFormat > ConsoleLogfmt {
+		return newConfigFieldError("ConsoleFormat", "is not a valid format")
+	}
+
+	if c.ConsoleColor < ColorAuto || c.ConsoleColor > ColorNever {
+		return newConfigFieldError("ConsoleColor", "is not a valid color mode")
+	}
+
+	if c.MaxLineSize < 0 {
+		return newConfigFieldError("MaxLineSize", "cannot be negative")
+	}
+
+	if c.LineSizePolicy < LineTruncate || c.LineSizePolicy > LineSplit {
+		return newConfigFieldError("LineSizePolicy", "is not a valid policy")
+	}
+
+	if c.RejectOldSamplesMaxAge < 0 {
+		return newConfigFieldError("RejectOldSamplesMaxAge", "cannot be negative")
+	}
+
+	if c.CreationGracePeriod < 0 {
+		return newConfigFieldError("CreationGracePeriod", "cannot be negative")
+	}
+
+	if c.HealthFailureThreshold < 0 {
+		return newConfigFieldError("HealthFailureThreshold", "cannot be negative")
+	}
+
 	return nil
 }
@@ -1,6 +1,8 @@
 package loki
 
 import (
+	"io"
+	"os"
 	"testing"
 	"time"
 
@@ -19,10 +21,35 @@ func TestConfigWithAllOptions(t *testing.T) {
 	assert.Equal(t, types.LevelInfo, cfg.LogLevel)
 	assert.NotNil(t, cfg.Labels)
 	assert.False(t, cfg.OnlyConsole)
+	assert.Equal(t, ConsoleHuman, cfg.ConsoleFormat)
+	assert.Nil(t, cfg.ConsoleWriter)
+	assert.Nil(t, cfg.ConsoleErrorWriter)
+	assert.Equal(t, ColorAuto, cfg.ConsoleColor)
+	assert.Equal(t, "2006-01-02 15:04:05", cfg.ConsoleTimeLayout)
+	assert.Nil(t, cfg.ConsoleTimeLocation)
 	assert.Equal(t, 100, cfg.BatchSize)
 	assert.Equal(t, 5*time.Second, cfg.FlushInterval)
 	assert.Equal(t, 3, cfg.MaxRetries)
 	assert.Equal(t, 10*time.Second, cfg.Timeout)
+	assert.Equal(t, []types.Level{types.LevelError, types.LevelPanic, types.LevelFatal}, cfg.StackTraceLevels)
+	assert.NotNil(t, cfg.ExitFunc)
+	assert.False(t, cfg.HealthCheckReady)
+	assert.Equal(t, 3, cfg.HealthFailureThreshold)
+	assert.Equal(t, 0, cfg.CircuitBreakerThreshold)
+	assert.Equal(t, 30*time.Second, cfg.CircuitBreakerTimeout)
+	assert.Equal(t, CircuitBreakerDrop, cfg.CircuitBreakerPolicy)
+	assert.Equal(t, 10000, cfg.CircuitBreakerBufferSize)
+	assert.Equal(t, 50, cfg.MaxLabelValues)
+	assert.Equal(t, LabelSanitize, cfg.LabelPolicy)
+	assert.Equal(t, 15, cfg.MaxLabels)
+	assert.Equal(t, 1024, cfg.MaxLabelNameLength)
+	assert.Equal(t, 2048, cfg.MaxLabelValueLength)
+	assert.Equal(t, 0, cfg.MaxLineSize)
+	assert.Equal(t, 0, cfg.MaxBatchBytes)
+	assert.False(t, cfg.StrictTimestamps)
+	assert.Equal(t, 168*time.Hour, cfg.RejectOldSamplesMaxAge)
+	assert.Equal(t, 10*time.Minute, cfg.CreationGracePeriod)
+	assert.Equal(t, LineTruncate, cfg.LineSizePolicy)
 
 	// Apply remaining configurable options
 	WithAppName("test-app")(cfg)
@@ -33,8 +60,43 @@ func TestConfigWithAllOptions(t *testing.T) {
 	WithLogLevel(types.LevelDebug)(cfg)
 	WithLabels(types.Labels{"env": "test", "region": "us-east"})(cfg)
 	WithOnlyConsole(true)(cfg)
+	WithConsoleFormat(ConsoleLogfmt)(cfg)
+	WithConsoleWriter(io.Discard, os.Stderr)(cfg)
+	WithConsoleColor(ColorNever)(cfg)
+	WithConsoleTime(time.RFC3339, time.UTC)(cfg)
+	WithConsoleTime("", time.UTC)(cfg)
 	WithBatchSize(200)(cfg)
 	WithFlushInterval(10 * time.Second)(cfg)
+	WithRateLimit(50, 1024)(cfg)
+	WithDedupe(30 * time.Second)(cfg)
+	WithRedaction(RedactCredentials())(cfg)
+	WithHooks(HostnameHook())(cfg)
+	WithStackTraceLevels(types.LevelFatal)(cfg)
+	WithRepanicOnRecover(true)(cfg)
+	WithTraceContextExtractor(W3CTraceContext)(cfg)
+	exitCode := 0
+	WithExitFunc(func(code int) { exitCode = code })(cfg)
+	WithRedaction(RedactEmails())(cfg)
+	WithRateLimitAction(RateLimitDowngrade)(cfg)
+	WithOnRateLimited(func(*types.Entry, RateLimitAction) {})(cfg)
+	WithHealthCheckReady(true)(cfg)
+	WithMetadataKeys("trace_id")(cfg)
+	WithPromotedFields("http_status_class")(cfg)
+	WithLabelPolicy(LabelDrop)(cfg)
+	WithLabelLimits(30, 512, 1024)(cfg)
+	WithMaxLabelValues(20)(cfg)
+	WithOnLabelOverflow(func(label, value string) {})(cfg)
+	WithMetadataKeys("user_id")(cfg)
+	WithHealthFailureThreshold(5)(cfg)
+	WithCircuitBreaker(5, time.Minute)(cfg)
+	WithCircuitBreakerPolicy(CircuitBreakerBuffer, 500)(cfg)
+	WithOnCircuitStateChange(func(from, to CircuitState) {})(cfg)
+	WithMaxLineSize(64*1024, LineSplit)(cfg)
+	WithMaxBatchBytes(1 << 20)(cfg)
+	WithDeadLetter(DeadLetterWriter(io.Discard))(cfg)
+	WithStrictTimestamps(true)(cfg)
+	WithTimestampWindow(30*24*time.Hour, time.Minute)(cfg)
+	WithOnRejected(func([]*types.Entry, error) {})(cfg)
 
 	// Verify all options were applied
 	assert.Equal(t, "test-app", cfg.AppName)
@@ -47,10 +109,56 @@ func TestConfigWithAllOptions(t *testing.T) {
 	assert.Equal(t, "test", cfg.Labels["env"])
 	assert.Equal(t, "us-east", cfg.Labels["region"])
 	assert.True(t, cfg.OnlyConsole)
+	assert.Equal(t, ConsoleLogfmt, cfg.ConsoleFormat)
+	assert.Equal(t, io.Discard, cfg.ConsoleWriter)
+	assert.Equal(t, os.Stderr, cfg.ConsoleErrorWriter)
+	assert.Equal(t, ColorNever, cfg.ConsoleColor)
+	assert.Equal(t, time.RFC3339, cfg.ConsoleTimeLayout)
+	assert.Equal(t, time.UTC, cfg.ConsoleTimeLocation)
 	assert.Equal(t, 200, cfg.BatchSize)
 	assert.Equal(t, 10*time.Second, cfg.FlushInterval)
 	assert.Equal(t, 3, cfg.MaxRetries)
 	assert.Equal(t, 10*time.Second, cfg.Timeout)
+	assert.Equal(t, 50.0, cfg.RateLimitLines)
+	assert.Equal(t, 1024.0, cfg.RateLimitBytes)
+	assert.Equal(t, RateLimitDowngrade, cfg.RateLimitAction)
+	assert.NotNil(t, cfg.OnRateLimited)
+	assert.Equal(t, 30*time.Second, cfg.DedupeWindow)
+	assert.Len(t, cfg.Redaction, 2)
+	assert.Len(t, cfg.Hooks, 1)
+	assert.Equal(t, []types.Level{types.LevelFatal}, cfg.StackTraceLevels)
+
+	// Without arguments the list is empty, not nil, so stack traces are disabled
+	WithStackTraceLevels()(cfg)
+	assert.NotNil(t, cfg.StackTraceLevels)
+	assert.Empty(t, cfg.StackTraceLevels)
+	assert.True(t, cfg.RepanicOnRecover)
+	assert.NotNil(t, cfg.TraceContextExtractor)
+	assert.True(t, cfg.HealthCheckReady)
+	assert.Equal(t, []string{"trace_id", "user_id"}, cfg.MetadataKeys)
+	assert.Equal(t, []string{"http_status_class"}, cfg.PromotedFields)
+	assert.Equal(t, LabelDrop, cfg.LabelPolicy)
+	assert.Equal(t, 30, cfg.MaxLabels)
+	assert.Equal(t, 512, cfg.MaxLabelNameLength)
+	assert.Equal(t, 1024, cfg.MaxLabelValueLength)
+	assert.Equal(t, 20, cfg.MaxLabelValues)
+	assert.NotNil(t, cfg.OnLabelOverflow)
+	assert.Equal(t, 5, cfg.HealthFailureThreshold)
+	assert.Equal(t, 5, cfg.CircuitBreakerThreshold)
+	assert.Equal(t, time.Minute, cfg.CircuitBreakerTimeout)
+	assert.Equal(t, CircuitBreakerBuffer, cfg.CircuitBreakerPolicy)
+	assert.Equal(t, 500, cfg.CircuitBreakerBufferSize)
+	assert.NotNil(t, cfg.OnCircuitStateChange)
+	assert.Equal(t, 64*1024, cfg.MaxLineSize)
+	assert.Equal(t, LineSplit, cfg.LineSizePolicy)
+	assert.Equal(t, 1<<20, cfg.MaxBatchBytes)
+	assert.NotNil(t, cfg.DeadLetter)
+	assert.NotNil(t, cfg.OnRejected)
+	assert.True(t, cfg.StrictTimestamps)
+	assert.Equal(t, 30*24*time.Hour, cfg.RejectOldSamplesMaxAge)
+	assert.Equal(t, time.Minute, cfg.CreationGracePeriod)
+	cfg.ExitFunc(2)
+	assert.Equal(t, 2, exitCode)
 }
 
 func TestConfigValidate(t *testing.T) {
@@ -105,6 +213,139 @@ func TestConfigValidate(t *testing.T) {
 			errorField: "Timeout",
 			errorMsg:   "must be greater than 0",
 		},
+		{
+			name:       "negative DedupeWindow",
+			modify:     func(c *Config) { c.DedupeWindow = -1 },
+			errorField: "DedupeWindow",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "negative RateLimitLines",
+			modify:     func(c *Config) { c.RateLimitLines = -1 },
+			errorField: "RateLimitLines",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "negative RateLimitBytes",
+			modify:     func(c *Config) { c.RateLimitBytes = -1 },
+			errorField: "RateLimitBytes",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "negative CircuitBreakerThreshold",
+			modify:     func(c *Config) { c.CircuitBreakerThreshold = -1 },
+			errorField: "CircuitBreakerThreshold",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "missing CircuitBreakerTimeout",
+			modify:     func(c *Config) { c.CircuitBreakerThreshold = 3 },
+			errorField: "CircuitBreakerTimeout",
+			errorMsg:   "must be greater than 0",
+		},
+		{
+			name: "missing CircuitBreakerBufferSize",
+			modify: func(c *Config) {
+				c.CircuitBreakerThreshold = 3
+				c.CircuitBreakerTimeout = time.Second
+				c.CircuitBreakerPolicy = CircuitBreakerBuffer
+			},
+			errorField: "CircuitBreakerBufferSize",
+			errorMsg:   "must be greater than 0",
+		},
+		{
+			name:       "negative MaxLabels",
+			modify:     func(c *Config) { c.MaxLabels = -1 },
+			errorField: "MaxLabels",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "negative MaxLabelNameLength",
+			modify:     func(c *Config) { c.MaxLabelNameLength = -1 },
+			errorField: "MaxLabelNameLength",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "negative MaxLabelValueLength",
+			modify:     func(c *Config) { c.MaxLabelValueLength = -1 },
+			errorField: "MaxLabelValueLength",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name: "invalid Labels with LabelReject",
+			modify: func(c *Config) {
+				c.LabelPolicy = LabelReject
+				c.Labels = types.Labels{"__name__": "x"}
+			},
+			errorField: "Labels",
+			errorMsg:   "reserved",
+		},
+		{
+			name:       "missing MaxLabelValues",
+			modify:     func(c *Config) { c.PromotedFields = []string{"tenant"} },
+			errorField: "MaxLabelValues",
+			errorMsg:   "must be greater than 0",
+		},
+		{
+			name:       "negative MaxBatchBytes",
+			modify:     func(c *Config) { c.MaxBatchBytes = -1 },
+			errorField: "MaxBatchBytes",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "invalid ConsoleFormat",
+			modify:     func(c *Config) { c.ConsoleFormat = ConsoleFormat(99) },
+			errorField: "ConsoleFormat",
+			errorMsg:   "is not a valid format",
+		},
+		{
+			name:       "invalid ConsoleColor",
+			modify:     func(c *Config) { c.ConsoleColor = ConsoleColor(99) },
+			errorField: "ConsoleColor",
+			errorMsg:   "is not a valid color mode",
+		},
+		{
+			name:       "negative MaxLineSize",
+			modify:     func(c *Config) { c.MaxLineSize = -1 },
+			errorField: "MaxLineSize",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "invalid LineSizePolicy",
+			modify:     func(c *Config) { c.LineSizePolicy = LineSizePolicy(99) },
+			errorField: "LineSizePolicy",
+			errorMsg:   "is not a valid policy",
+		},
+		{
+			name:       "invalid RateLimitAction",
+			modify:     func(c *Config) { c.RateLimitAction = RateLimitAction(-1) },
+			errorField: "RateLimitAction",
+			errorMsg:   "is not a valid action",
+		},
+		{
+			name:       "invalid CircuitBreakerPolicy",
+			modify:     func(c *Config) { c.CircuitBreakerPolicy = CircuitBreakerPolicy(99) },
+			errorField: "CircuitBreakerPolicy",
+			errorMsg:   "is not a valid policy",
+		},
+		{
+			name:       "negative RejectOldSamplesMaxAge",
+			modify:     func(c *Config) { c.RejectOldSamplesMaxAge = -1 },
+			errorField: "RejectOldSamplesMaxAge",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "negative CreationGracePeriod",
+			modify:     func(c *Config) { c.CreationGracePeriod = -1 },
+			errorField: "CreationGracePeriod",
+			errorMsg:   "cannot be negative",
+		},
+		{
+			name:       "negative HealthFailureThreshold",
+			modify:     func(c *Config) { c.HealthFailureThreshold = -1 },
+			errorField: "HealthFailureThreshold",
+			errorMsg:   "cannot be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -122,3 +363,29 @@ func TestConfigValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigEnumStrings(t *testing.T) {
+	assert.Equal(t, "closed", CircuitClosed.String())
+	assert.Equal(t, "open", CircuitOpen.String())
+	assert.Equal(t, "half-open", CircuitHalfOpen.String())
+	assert.Equal(t, "unknown", CircuitState(99).String())
+	assert.Equal(t, "drop", CircuitBreakerDrop.String())
+	assert.Equal(t, "buffer", CircuitBreakerBuffer.String())
+	assert.Equal(t, "unknown", CircuitBreakerPolicy(99).String())
+	assert.Equal(t, "sanitize", LabelSanitize.String())
+	assert.Equal(t, "drop", LabelDrop.String())
+	assert.Equal(t, "reject", LabelReject.String())
+	assert.Equal(t, "unknown", LabelPolicy(99).String())
+	assert.Equal(t, "truncate", LineTruncate.String())
+	assert.Equal(t, "trim-fields", LineTrimFields.String())
+	assert.Equal(t, "split", LineSplit.String())
+	assert.Equal(t, "unknown", LineSizePolicy(99).String())
+	assert.Equal(t, "human", ConsoleHuman.String())
+	assert.Equal(t, "json", ConsoleJSON.String())
+	assert.Equal(t, "logfmt", ConsoleLogfmt.String())
+	assert.Equal(t, "unknown", ConsoleFormat(99).String())
+	assert.Equal(t, "auto", ColorAuto.String())
+	assert.Equal(t, "always", ColorAlways.String())
+	assert.Equal(t, "never", ColorNever.String())
+	assert.Equal(t, "unknown", ConsoleColor(99).String())
+}
@@ -0,0 +1,29 @@
+package loki
+
+import "context"
+
+// contextKey is the type of the context key used to store a Logger.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the given logger.
+// Use it to pass request-scoped child loggers down the call chain.
+//
+// Example:
+//
+//	reqLogger := logger.WithFields(map[string]any{"request_id": id})
+//	ctx = loki.NewContext(ctx, reqLogger)
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, if any.
+//
+// Example:
+//
+//	if logger, ok := loki.FromContext(ctx); ok {
+//		logger.Info(ctx, "processing order", nil)
+//	}
+func FromContext(ctx context.Context) (*Logger, bool) {
+	logger, ok := ctx.Value(contextKey{}).(*Logger)
+	return logger, ok && logger != nil
+}
@@ -0,0 +1,22 @@
+package loki
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	logger := newTestLogger(t)
+
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = FromContext(NewContext(context.Background(), nil))
+	assert.False(t, ok)
+
+	got, ok := FromContext(NewContext(context.Background(), logger))
+	assert.True(t, ok)
+	assert.Same(t, logger, got)
+}
@@ -0,0 +1,97 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// DeadLetter receives the entries Loki rejected with a 400 status, such as out-of-order
+// entries or lines Loki considers malformed, so they can be kept instead of lost.
+// The rest of their batch is still pushed. Implementations must be safe for concurrent use.
+type DeadLetter interface {
+	Write(ctx context.Context, entries ...*types.Entry) error
+}
+
+// DeadLetterWriter returns a DeadLetter writing every rejected entry to w as a JSON line
+// with its timestamp, level, labels, message, fields and structured metadata.
+//
+// Example:
+//
+//	f, err := os.OpenFile("rejected.jsonl", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+//	if err != nil {
+//		return err
+//	}
+//	logger, err := loki.New(loki.DefaultConfig(), loki.WithDeadLetter(loki.DeadLetterWriter(f)))
+func DeadLetterWriter(w io.Writer) DeadLetter {
+	return &writerDeadLetter{w: w}
+}
+
+// writerDeadLetter writes rejected entries to an io.Writer as JSON lines.
+type writerDeadLetter struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// deadLetterRecord is the JSON representation of a rejected entry.
+type deadLetterRecord struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Level     string            `json:"level"`
+	Labels    types.Labels      `json:"labels"`
+	Message   string            `json:"message"`
+	Fields    map[string]any    `json:"fields,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Write encodes entries as JSON lines.
+func (d *writerDeadLetter) Write(ctx context.Context, entries ...*types.Entry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	encoder := json.NewEncoder(d.w)
+	for _, entry := range entries {
+		err := encoder.Encode(deadLetterRecord{
+			Timestamp: entry.Timestamp,
+			Level:     entry.Level.String(),
+			Labels:    entry.Labels,
+			Message:   entry.Message,
+			Fields:    entry.Fields,
+			Metadata:  entry.Metadata,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// onRejected combines Config.DeadLetter and Config.OnRejected into the callback receiving
+// entries rejected by Loki. It returns nil when neither is set, so that rejections are
+// reported through OnFlushError instead.
+func (l *Logger) onRejected() func(entries []*types.Entry, err error) {
+	deadLetter, fn := l.config.DeadLetter, l.config.OnRejected
+	if deadLetter == nil && fn == nil {
+		return nil
+	}
+
+	return func(entries []*types.Entry, err error) {
+		if deadLetter != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), l.config.Timeout)
+			defer cancel()
+
+			if writeErr := deadLetter.Write(ctx, entries...); writeErr != nil && l.config.OnFlushError != nil {
+				l.config.OnFlushError(fmt.Errorf("failed to write %d rejected entries to the dead letter: %w", len(entries), writeErr))
+			}
+		}
+
+		if fn != nil {
+			fn(entries, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterWriter(t *testing.T) {
+	var buf bytes.Buffer
+	dl := DeadLetterWriter(&buf)
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := dl.Write(context.Background(), &types.Entry{
+		Level:     types.LevelWarn,
+		Message:   "late event",
+		Fields:    map[string]any{"order": "o-1"},
+		Timestamp: ts,
+		Labels:    types.Labels{"app": "test"},
+		Metadata:  map[string]string{"trace_id": "abc"},
+	}, &types.Entry{Level: types.LevelInfo, Message: "second", Timestamp: ts})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "2024-01-02T03:04:05Z", record["timestamp"])
+	assert.Equal(t, "warn", record["level"])
+	assert.Equal(t, "late event", record["message"])
+	assert.Equal(t, map[string]any{"app": "test"}, record["labels"])
+	assert.Equal(t, map[string]any{"order": "o-1"}, record["fields"])
+	assert.Equal(t, map[string]any{"trace_id": "abc"}, record["metadata"])
+
+	var second map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "second", second["message"])
+	assert.NotContains(t, second, "fields")
+}
+
+type failingDeadLetter struct{}
+
+func (failingDeadLetter) Write(context.Context, ...*types.Entry) error {
+	return errors.New("disk full")
+}
+
+func TestLoggerDeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("stale")) {
+			http.Error(w, "entry too far behind", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var deadLetter bytes.Buffer
+	var rejected []string
+	var rejectErr error
+	logger, err := New(
+		DefaultConfig(),
+		WithLokiHost(srv.URL),
+		WithBatchSize(3),
+		WithFlushInterval(time.Hour),
+		WithDeadLetter(DeadLetterWriter(&deadLetter)),
+		WithOnRejected(func(entries []*types.Entry, err error) {
+			for _, entry := range entries {
+				rejected = append(rejected, entry.Message)
+			}
+			rejectErr = err
+		}),
+	)
+	require.NoError(t, err)
+	defer func() { _ = logger.Close() }()
+
+	ctx := context.Background()
+	_ = captureStdout(t, func() {
+		logger.Info(ctx, "fresh one", nil)
+		logger.Info(ctx, "stale event", nil)
+		logger.Info(ctx, "fresh two", nil)
+	})
+
+	assert.Equal(t, []string{"stale event"}, rejected)
+	assert.ErrorContains(t, rejectErr, "400")
+	assert.Contains(t, deadLetter.String(), `"message":"stale event"`)
+
+	stats := logger.Stats().Transports["loki"]
+	assert.Equal(t, uint64(2), stats.Pushed)
+	assert.Equal(t, uint64(1), stats.Rejected)
+	assert.Zero(t, stats.Failed)
+
+	// Dead letter failures are reported through OnFlushError
+	var flushErrors []error
+	logger.config.DeadLetter = failingDeadLetter{}
+	logger.config.OnRejected = nil
+	logger.config.OnFlushError = func(err error) { flushErrors = append(flushErrors, err) }
+	fn := logger.onRejected()
+	fn([]*types.Entry{{Message: "stale"}}, errors.New("rejected"))
+	require.Len(t, flushErrors, 1)
+	assert.ErrorContains(t, flushErrors[0], "disk full")
+}
@@ -1,6 +1,12 @@
 package loki
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/internal/labelrules"
+)
 
 // Public Error Types
 // These types are exported so users can use errors.As() to inspect them
@@ -59,9 +65,43 @@ func (e *ClientError) Unwrap() error {
 	return e.Cause
 }
 
+// LabelError represents a label that breaks Loki's naming rules or the configured limits.
+// Label is empty when the number of labels exceeds the limit.
+type LabelError struct {
+	Label   string // The offending label name (optional)
+	Message string // Human-readable error message
+}
+
+func (e *LabelError) Error() string {
+	if e.Label != "" {
+		return fmt.Sprintf("loki: invalid label [%s]: %s", e.Label, e.Message)
+	}
+	return fmt.Sprintf("loki: invalid labels: %s", e.Message)
+}
+
+// TimestampError is returned by Logger.LogAt when the timestamp of an entry is outside
+// the window Loki accepts. The entry is dropped.
+type TimestampError struct {
+	Timestamp time.Time // The rejected timestamp
+	Message   string    // Human-readable error message
+}
+
+func (e *TimestampError) Error() string {
+	return fmt.Sprintf("loki: invalid timestamp [%s]: %s", e.Timestamp.Format(time.RFC3339Nano), e.Message)
+}
+
 // Internal constructor functions
 
 // newConfigFieldError creates a configuration error with a specific field.
 func newConfigFieldError(field, message string) error {
 	return &ConfigError{Field: field, Message: message}
 }
+
+// newLabelError converts a label rule violation into a LabelError.
+func newLabelError(err error) error {
+	var ruleErr *labelrules.Error
+	if errors.As(err, &ruleErr) {
+		return &LabelError{Label: ruleErr.Label, Message: ruleErr.Reason}
+	}
+	return &LabelError{Message: err.Error()}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -105,3 +106,20 @@ func TestClientError(t *testing.T) {
 	assert.Equal(t, "POST", clientErr.Method)
 	assert.Equal(t, "http://localhost:3100/loki/api/v1/push", clientErr.URL)
 }
+
+func TestLabelError(t *testing.T) {
+	err := &LabelError{Label: "k8s.pod", Message: "name must match [a-zA-Z_][a-zA-Z0-9_]*"}
+	assert.Equal(t, "loki: invalid label [k8s.pod]: name must match [a-zA-Z_][a-zA-Z0-9_]*", err.Error())
+
+	errNoLabel := &LabelError{Message: "16 labels exceed the maximum of 15"}
+	assert.Equal(t, "loki: invalid labels: 16 labels exceed the maximum of 15", errNoLabel.Error())
+
+	var labelErr *LabelError
+	require.ErrorAs(t, newLabelError(errors.New("plain")), &labelErr)
+	assert.Equal(t, "plain", labelErr.Message)
+}
+
+func TestTimestampError(t *testing.T) {
+	err := &TimestampError{Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Message: "older than 168h0m0s"}
+	assert.Equal(t, "loki: invalid timestamp [2024-01-02T03:04:05Z]: older than 168h0m0s", err.Error())
+}
@@ -0,0 +1,173 @@
+package loki
+
+import (
+	"context"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// Event is a log entry built with the fluent API started by Logger.At.
+// Fields, labels, an error and a timestamp are attached by chaining methods,
+// and Msg sends the entry through the same pipeline as the other logging methods.
+//
+// When the level is disabled, At returns a nil *Event and every method is a no-op.
+// Building an entry that will not be logged with the typed setters (Str, Int, Dur, ...)
+// does not allocate; Field takes an any, so most non-constant values are allocated at
+// the call site even then. An Event must not be used after Msg and is not safe for
+// concurrent use.
+type Event struct {
+	logger *Logger
+	level  types.Level
+	ts     time.Time
+	fields map[string]any
+	labels types.Labels
+}
+
+// At starts building an entry at the given level. It returns nil when the level is
+// disabled; the methods of a nil *Event do nothing.
+//
+// Example:
+//
+//	logger.At(types.LevelWarn).
+//		Label("component", "db").
+//		Int("rows", rows).
+//		Err(err).
+//		Msg(ctx, "slow query")
+func (l *Logger) At(level types.Level) *Event {
+	if !level.IsEnabled(l.config.LogLevel) {
+		return nil
+	}
+	return &Event{logger: l, level: level}
+}
+
+// Enabled reports whether the entry will be logged, to skip building expensive fields.
+func (e *Event) Enabled() bool {
+	return e != nil
+}
+
+// Field adds a structured field. Fields of the logger (see Logger.WithFields) are kept
+// unless overridden. Prefer the typed setters on hot paths: boxing value into an any
+// allocates even when the event is disabled.
+func (e *Event) Field(key string, value any) *Event {
+	if e == nil {
+		return nil
+	}
+	if e.fields == nil {
+		e.fields = make(map[string]any)
+	}
+	e.fields[key] = value
+	return e
+}
+
+// Str adds a string field.
+func (e *Event) Str(key, value string) *Event {
+	if e == nil {
+		return nil
+	}
+	return e.Field(key, value)
+}
+
+// Int adds an int field.
+func (e *Event) Int(key string, value int) *Event {
+	if e == nil {
+		return nil
+	}
+	return e.Field(key, value)
+}
+
+// Int64 adds an int64 field.
+func (e *Event) Int64(key string, value int64) *Event {
+	if e == nil {
+		return nil
+	}
+	return e.Field(key, value)
+}
+
+// Float64 adds a float64 field.
+func (e *Event) Float64(key string, value float64) *Event {
+	if e == nil {
+		return nil
+	}
+	return e.Field(key, value)
+}
+
+// Bool adds a bool field.
+func (e *Event) Bool(key string, value bool) *Event {
+	if e == nil {
+		return nil
+	}
+	return e.Field(key, value)
+}
+
+// Dur adds a time.Duration field.
+func (e *Event) Dur(key string, value time.Duration) *Event {
+	if e == nil {
+		return nil
+	}
+	return e.Field(key, value)
+}
+
+// Fields adds several structured fields.
+func (e *Event) Fields(fields map[string]any) *Event {
+	if e == nil {
+		return nil
+	}
+	for k, v := range fields {
+		e.Field(k, v)
+	}
+	return e
+}
+
+// Err adds err as the "error" field, in the structured form of ErrorField.
+// A nil error is ignored.
+func (e *Event) Err(err error) *Event {
+	if e == nil || err == nil {
+		return e
+	}
+	return e.Field("error", ErrorField(err))
+}
+
+// Label adds a label to this entry only, on top of the logger's labels.
+// Labels are indexed by Loki and must have low cardinality; the system labels
+// (app, level, version, environment) cannot be overridden.
+func (e *Event) Label(name, value string) *Event {
+	if e == nil {
+		return nil
+	}
+	if e.labels == nil {
+		e.labels = make(types.Labels)
+	}
+	e.labels[name] = value
+	return e
+}
+
+// Time sets the timestamp of the entry instead of the current time, as Logger.LogAt does.
+// An entry whose timestamp is outside the window Loki accepts is dropped by Msg.
+func (e *Event) Time(ts time.Time) *Event {
+	if e == nil {
+		return nil
+	}
+	e.ts = ts
+	return e
+}
+
+// Msg logs the entry with the given message. Like Logger.Log, it never panics or exits,
+// whatever the level.
+//
+// Like Logger.LogAt, it drops an entry whose timestamp (see Event.Time) is outside the
+// window Loki accepts, counts it in Stats.OutOfWindow and returns a *TimestampError.
+// It returns nil otherwise, including when the event is disabled.
+func (e *Event) Msg(ctx context.Context, message string) error {
+	if e == nil {
+		return nil
+	}
+
+	if err := e.logger.checkTimestamp(e.ts); err != nil {
+		e.logger.metrics.OutOfWindow.Inc()
+		return err
+	}
+
+	e.logger.logAt(ctx, e.ts, e.level, message, e.fields, e.labels)
+	return nil
+}
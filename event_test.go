@@ -0,0 +1,127 @@
+package loki
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvent(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.config.LogLevel = types.LevelInfo
+	logger.config.Labels = types.Labels{"team": "core"}
+	ctx := context.Background()
+
+	child := logger.WithLabels(types.Labels{"service": "orders"}).WithFields(map[string]any{"request_id": "r-1"})
+	child.At(types.LevelWarn).
+		Label("component", "db").
+		Label("level", "info").
+		Field("rows", 1200).
+		Fields(map[string]any{"table": "orders"}).
+		Err(errors.New("timeout")).
+		Msg(ctx, "slow query")
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, types.LevelWarn, entry.Level)
+	assert.Equal(t, "slow query", entry.Message)
+	assert.Equal(t, "db", entry.Labels["component"])
+	assert.Equal(t, "orders", entry.Labels["service"])
+	assert.Equal(t, "core", entry.Labels["team"])
+	assert.Equal(t, "warn", entry.Labels["level"], "system labels cannot be overridden")
+	assert.Equal(t, 1200, entry.Fields["rows"])
+	assert.Equal(t, "orders", entry.Fields["table"])
+	assert.Equal(t, "r-1", entry.Fields["request_id"])
+	assert.Equal(t, "timeout", entry.Fields["error"].(map[string]any)["message"])
+	assert.NotEmpty(t, entry.Fields["file"])
+
+	// Labels of an event do not leak into the logger
+	assert.NotContains(t, child.config.Labels, "component")
+
+	// A nil error is ignored
+	logger.At(types.LevelInfo).Err(nil).Msg(ctx, "no error")
+	require.Len(t, mock.GetEntries(), 2)
+	assert.NotContains(t, mock.GetEntries()[1].Fields, "error")
+}
+
+func TestEventTime(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.config.LogLevel = types.LevelInfo
+	logger.config.RejectOldSamplesMaxAge = 24 * time.Hour
+	ctx := context.Background()
+
+	ts := time.Now().Add(-time.Hour)
+	require.NoError(t, logger.At(types.LevelInfo).Time(ts).Msg(ctx, "replayed"))
+
+	// Dropped and reported like LogAt
+	err := logger.At(types.LevelInfo).Time(ts.Add(-48*time.Hour)).Msg(ctx, "too old")
+	var tsErr *TimestampError
+	require.ErrorAs(t, err, &tsErr)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.True(t, ts.Equal(entries[0].Timestamp))
+	assert.Equal(t, uint64(1), logger.Stats().OutOfWindow)
+}
+
+func TestEventDisabled(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.config.LogLevel = types.LevelInfo
+	ctx := context.Background()
+	err := errors.New("boom")
+
+	event := logger.At(types.LevelDebug)
+	assert.Nil(t, event)
+	assert.False(t, event.Enabled())
+	assert.True(t, logger.At(types.LevelError).Enabled())
+	assert.NoError(t, event.Msg(ctx, "never logged"))
+
+	// Typed setters do not allocate for non-constant values
+	rows, table, ratio, elapsed := 1200, "orders", 0.5, 3*time.Second
+	allocs := testing.AllocsPerRun(100, func() {
+		rows++
+		_ = logger.At(types.LevelDebug).
+			Label("component", "db").
+			Str("table", table).
+			Int("rows", rows).
+			Int64("bytes", int64(rows)).
+			Float64("ratio", ratio).
+			Bool("cached", rows%2 == 0).
+			Dur("elapsed", elapsed).
+			Fields(nil).
+			Err(err).
+			Time(time.Time{}).
+			Msg(ctx, "never logged")
+	})
+	assert.Zero(t, allocs)
+	assert.Empty(t, mock.GetEntries())
+}
+
+func TestEventTypedSetters(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.config.LogLevel = types.LevelInfo
+
+	logger.At(types.LevelInfo).
+		Str("table", "orders").
+		Int("rows", 1200).
+		Int64("bytes", 1<<40).
+		Float64("ratio", 0.5).
+		Bool("cached", true).
+		Dur("elapsed", 3*time.Second).
+		Msg(context.Background(), "query")
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "orders", entries[0].Fields["table"])
+	assert.Equal(t, 1200, entries[0].Fields["rows"])
+	assert.Equal(t, int64(1<<40), entries[0].Fields["bytes"])
+	assert.Equal(t, 0.5, entries[0].Fields["ratio"])
+	assert.Equal(t, true, entries[0].Fields["cached"])
+	assert.Equal(t, 3*time.Second, entries[0].Fields["elapsed"])
+}
@@ -0,0 +1,82 @@
+package loki
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxErrorChainDepth limits how many wrapped errors ErrorField records.
+const maxErrorChainDepth = 16
+
+// ErrorField returns a structured representation of err, meant to be stored under the "error" field.
+// It records the error message, its Go type and the chain of wrapped errors (including every
+// branch of errors.Join), which keeps the details queryable with LogQL `| json`.
+// Returns nil if err is nil.
+//
+// Example:
+//
+//	logger.Error(ctx, "payment failed", map[string]any{
+//		"error": loki.ErrorField(err),
+//	})
+//
+// produces:
+//
+//	"error": {
+//		"message": "charge: connection refused",
+//		"type": "*fmt.wrapError",
+//		"chain": [{"message": "connection refused", "type": "*net.OpError"}]
+//	}
+func ErrorField(err error) map[string]any {
+	if err == nil {
+		return nil
+	}
+
+	field := map[string]any{
+		"message": err.Error(),
+		"type":    fmt.Sprintf("%T", err),
+	}
+
+	if chain := errorChain(err); len(chain) > 0 {
+		field["chain"] = chain
+	}
+
+	return field
+}
+
+// errorChain walks the errors wrapped by err breadth-first and describes each of them.
+func errorChain(err error) []any {
+	var chain []any
+
+	queue := unwrapAll(err)
+	for len(queue) > 0 && len(chain) < maxErrorChainDepth {
+		current := queue[0]
+		queue = append(queue[1:], unwrapAll(current)...)
+
+		chain = append(chain, map[string]any{
+			"message": current.Error(),
+			"type":    fmt.Sprintf("%T", current),
+		})
+	}
+
+	return chain
+}
+
+// unwrapAll returns the errors directly wrapped by err, supporting both
+// Unwrap() error and Unwrap() []error.
+func unwrapAll(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var wrapped []error
+		for _, e := range joined.Unwrap() {
+			if e != nil {
+				wrapped = append(wrapped, e)
+			}
+		}
+		return wrapped
+	}
+
+	if next := errors.Unwrap(err); next != nil {
+		return []error{next}
+	}
+
+	return nil
+}
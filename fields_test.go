@@ -0,0 +1,38 @@
+package loki
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorField(t *testing.T) {
+	assert.Nil(t, ErrorField(nil))
+
+	field := ErrorField(errors.New("boom"))
+	assert.Equal(t, "boom", field["message"])
+	assert.Equal(t, "*errors.errorString", field["type"])
+	assert.NotContains(t, field, "chain")
+
+	pathErr := &fs.PathError{Op: "open", Path: "/tmp/x", Err: fs.ErrNotExist}
+	wrapped := fmt.Errorf("load config: %w", pathErr)
+	field = ErrorField(wrapped)
+	assert.Equal(t, "load config: open /tmp/x: file does not exist", field["message"])
+	assert.Equal(t, "*fmt.wrapError", field["type"])
+
+	chain := field["chain"].([]any)
+	require.Len(t, chain, 2)
+	assert.Equal(t, map[string]any{"message": "open /tmp/x: file does not exist", "type": "*fs.PathError"}, chain[0])
+	assert.Equal(t, map[string]any{"message": "file does not exist", "type": "*errors.errorString"}, chain[1])
+
+	joined := errors.Join(errors.New("first"), fmt.Errorf("second: %w", errors.New("cause")))
+	chain = ErrorField(joined)["chain"].([]any)
+	require.Len(t, chain, 3)
+	assert.Equal(t, "first", chain[0].(map[string]any)["message"])
+	assert.Equal(t, "second: cause", chain[1].(map[string]any)["message"])
+	assert.Equal(t, "cause", chain[2].(map[string]any)["message"])
+}
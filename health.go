@@ -0,0 +1,167 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/internal/client"
+)
+
+// HealthStatus summarizes whether logs are reaching their destination.
+type HealthStatus string
+
+const (
+	// HealthOK means every transport is delivering entries.
+	HealthOK HealthStatus = "ok"
+
+	// HealthDegraded means recent pushes failed but the failure threshold has not been reached.
+	HealthDegraded HealthStatus = "degraded"
+
+	// HealthDown means a transport reached the failure threshold, has an open circuit
+	// or failed its readiness check.
+	HealthDown HealthStatus = "down"
+)
+
+// severity orders statuses from best to worst.
+func (s HealthStatus) severity() int {
+	switch s {
+	case HealthDegraded:
+		return 1
+	case HealthDown:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Health is the result of Logger.Health.
+type Health struct {
+	Status     HealthStatus               `json:"status"`     // worst status across transports
+	Transports map[string]TransportHealth `json:"transports"` // keyed by transport name
+}
+
+// TransportHealth describes the state of a single transport.
+// Push-related fields are only populated for the Loki transport.
+type TransportHealth struct {
+	Status              HealthStatus `json:"status"`
+	LastSuccess         time.Time    `json:"last_success,omitzero"` // time of the last successful push
+	LastError           string       `json:"last_error,omitempty"`  // last push error since startup
+	ConsecutiveFailures int          `json:"consecutive_failures"`  // failed pushes since the last success
+	QueueDepth          int          `json:"queue_depth"`           // entries buffered and not yet pushed
+	Circuit             string       `json:"circuit,omitempty"`     // circuit breaker state, if enabled
+	ReadyError          string       `json:"ready_error,omitempty"` // readiness check failure, if checked
+}
+
+// queueDepther is implemented by transports that buffer entries.
+type queueDepther interface {
+	QueueDepth() int
+}
+
+// circuitStater is implemented by transports guarded by a circuit breaker.
+type circuitStater interface {
+	CircuitState() client.BreakerState
+}
+
+// readyChecker is implemented by transports whose destination exposes a readiness check.
+type readyChecker interface {
+	Ready(ctx context.Context) error
+}
+
+// Health reports whether each transport is delivering entries, based on the outcome
+// of recent pushes. When Config.HealthCheckReady is set, it also queries Loki's /ready
+// endpoint, bounded by ctx and Config.Timeout.
+//
+// Example:
+//
+//	if h := logger.Health(ctx); h.Status == loki.HealthDown {
+//		// logs are not reaching Loki
+//	}
+func (l *Logger) Health(ctx context.Context) Health {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.config.Timeout)
+		defer cancel()
+	}
+
+	// Readiness checks are HTTP calls: do not hold the lock while they run
+	l.mu.RLock()
+	transports := slices.Clone(l.transports)
+	l.mu.RUnlock()
+
+	health := Health{
+		Status:     HealthOK,
+		Transports: make(map[string]TransportHealth, len(transports)),
+	}
+
+	for _, t := range transports {
+		m := l.metrics.Transport(t.Name())
+		th := TransportHealth{
+			Status:              HealthOK,
+			LastSuccess:         m.LastSuccess(),
+			LastError:           m.LastError(),
+			ConsecutiveFailures: m.ConsecutiveFailures(),
+		}
+
+		if q, ok := t.(queueDepther); ok {
+			th.QueueDepth = q.QueueDepth()
+		}
+
+		threshold := l.config.HealthFailureThreshold
+		switch {
+		case threshold > 0 && th.ConsecutiveFailures >= threshold:
+			th.Status = HealthDown
+		case th.ConsecutiveFailures > 0:
+			th.Status = HealthDegraded
+		}
+
+		// An open circuit means pushes are failing fast; half-open means a probe is pending
+		if c, ok := t.(circuitStater); ok && l.config.CircuitBreakerThreshold > 0 {
+			state := CircuitState(c.CircuitState())
+			th.Circuit = state.String()
+			switch state {
+			case CircuitOpen:
+				th.Status = HealthDown
+			case CircuitHalfOpen:
+				if th.Status == HealthOK {
+					th.Status = HealthDegraded
+				}
+			}
+		}
+
+		if r, ok := t.(readyChecker); ok && l.config.HealthCheckReady {
+			if err := r.Ready(ctx); err != nil {
+				th.ReadyError = err.Error()
+				th.Status = HealthDown
+			}
+		}
+
+		if th.Status.severity() > health.Status.severity() {
+			health.Status = th.Status
+		}
+		health.Transports[t.Name()] = th
+	}
+
+	return health
+}
+
+// HealthHandler returns an http.Handler suitable for Kubernetes probes.
+// It responds with the JSON-encoded Health and status 200, or 503 when the
+// logger is down. Degraded logging still responds 200.
+//
+// Example:
+//
+//	http.Handle("/healthz/logging", logger.HealthHandler())
+func (l *Logger) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := l.Health(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		if health.Status == HealthDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(health)
+	})
+}
@@ -0,0 +1,185 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/internal/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHealthTestLogger(t *testing.T, handler http.HandlerFunc, opts ...Option) *Logger {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := newTestConfig()
+	cfg.OnlyConsole = false
+	cfg.LokiHost = server.URL
+	cfg.MaxRetries = 0
+	cfg.HealthFailureThreshold = 2
+	logger, err := New(cfg, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = logger.Close() })
+
+	logger.transports = []transport.Transport{logger.transports[1]} // loki only, no console output
+	return logger
+}
+
+func TestLoggerHealth(t *testing.T) {
+	var failing atomic.Bool
+	logger := newHealthTestLogger(t, func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctx := context.Background()
+
+	// Nothing pushed yet
+	health := logger.Health(ctx)
+	assert.Equal(t, HealthOK, health.Status)
+	assert.True(t, health.Transports["loki"].LastSuccess.IsZero())
+
+	logger.Info(ctx, "queued", nil)
+	assert.Equal(t, 1, logger.Health(ctx).Transports["loki"].QueueDepth)
+
+	require.NoError(t, logger.Flush(ctx))
+	health = logger.Health(ctx)
+	assert.Equal(t, HealthOK, health.Status)
+	assert.False(t, health.Transports["loki"].LastSuccess.IsZero())
+	assert.Equal(t, 0, health.Transports["loki"].QueueDepth)
+
+	// One failure degrades, reaching the threshold marks the transport down
+	failing.Store(true)
+	logger.Info(ctx, "lost", nil)
+	require.Error(t, logger.Flush(ctx))
+	health = logger.Health(ctx)
+	assert.Equal(t, HealthDegraded, health.Status)
+	assert.Equal(t, 1, health.Transports["loki"].ConsecutiveFailures)
+	assert.Contains(t, health.Transports["loki"].LastError, "500")
+
+	logger.Info(ctx, "lost", nil)
+	require.Error(t, logger.Flush(ctx))
+	assert.Equal(t, HealthDown, logger.Health(ctx).Status)
+
+	// A successful push recovers
+	failing.Store(false)
+	logger.Info(ctx, "back", nil)
+	require.NoError(t, logger.Flush(ctx))
+	health = logger.Health(ctx)
+	assert.Equal(t, HealthOK, health.Status)
+	assert.Equal(t, 0, health.Transports["loki"].ConsecutiveFailures)
+}
+
+func TestLoggerHealth_Ready(t *testing.T) {
+	var ready atomic.Bool
+	logger := newHealthTestLogger(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ready", r.URL.Path)
+		if !ready.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("Ingester not ready"))
+			return
+		}
+		_, _ = w.Write([]byte("ready"))
+	}, WithHealthCheckReady(true))
+
+	health := logger.Health(context.Background())
+	assert.Equal(t, HealthDown, health.Status)
+	assert.Contains(t, health.Transports["loki"].ReadyError, "Ingester not ready")
+
+	ready.Store(true)
+	assert.Equal(t, HealthOK, logger.Health(context.Background()).Status)
+}
+
+func TestLoggerHealth_ReadyDoesNotHoldLock(t *testing.T) {
+	release := make(chan struct{})
+	logger := newHealthTestLogger(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte("ready"))
+	}, WithHealthCheckReady(true))
+	var releaseOnce sync.Once
+	unblock := func() { releaseOnce.Do(func() { close(release) }) }
+	t.Cleanup(unblock)
+
+	done := make(chan Health)
+	go func() { done <- logger.Health(context.Background()) }()
+
+	// The logger lock can be taken while the readiness check is in flight
+	locked := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		logger.mu.Lock()
+		logger.mu.Unlock()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Health held the logger lock during the readiness check")
+	}
+
+	unblock()
+	assert.Equal(t, HealthOK, (<-done).Status)
+}
+
+func TestLoggerHealthHandler(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+
+	rec := httptest.NewRecorder()
+	logger.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var health Health
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+	assert.Equal(t, HealthOK, health.Status)
+	assert.Contains(t, health.Transports, mock.Name())
+
+	// Transports report push outcomes through the shared metrics
+	logger.config.HealthFailureThreshold = 1
+	logger.metrics.Transport(mock.Name()).PushFailed(errors.New("connection refused"))
+
+	rec = httptest.NewRecorder()
+	logger.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"down"`)
+	assert.Contains(t, rec.Body.String(), "connection refused")
+}
+
+func TestLoggerHealth_CircuitBreaker(t *testing.T) {
+	var transitions []string
+	logger := newHealthTestLogger(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	},
+		WithCircuitBreaker(1, time.Hour),
+		WithCircuitBreakerPolicy(CircuitBreakerBuffer, 10),
+		WithOnCircuitStateChange(func(from, to CircuitState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		}),
+	)
+	ctx := context.Background()
+
+	assert.Equal(t, "closed", logger.Health(ctx).Transports["loki"].Circuit)
+
+	logger.Info(ctx, "lost", nil)
+	require.Error(t, logger.Flush(ctx))
+	logger.Info(ctx, "kept", nil)
+	require.Error(t, logger.Flush(ctx))
+
+	health := logger.Health(ctx)
+	assert.Equal(t, HealthDown, health.Status)
+	assert.Equal(t, "open", health.Transports["loki"].Circuit)
+	assert.Equal(t, 1, health.Transports["loki"].QueueDepth)
+	assert.Equal(t, []string{"closed->open"}, transitions)
+}
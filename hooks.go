@@ -0,0 +1,86 @@
+package loki
+
+import (
+	"context"
+	"os"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// Hook is an extension point between building an entry and writing it to transports.
+// Hooks run in registration order for every entry that passes the level check, and may
+// mutate the entry's message, fields and labels. Returning false drops the entry and
+// skips the remaining hooks. Implementations must be safe for concurrent use.
+//
+// Hooks run before redaction, so data they add is redacted like any other field.
+type Hook interface {
+	Run(ctx context.Context, entry *types.Entry) (keep bool)
+}
+
+// HookFunc adapts an ordinary function to the Hook interface.
+type HookFunc func(ctx context.Context, entry *types.Entry) (keep bool)
+
+// Run calls f(ctx, entry).
+func (f HookFunc) Run(ctx context.Context, entry *types.Entry) bool {
+	return f(ctx, entry)
+}
+
+// HostnameHook returns a hook that adds the machine hostname as the "hostname" field.
+// The hostname is resolved once; if it cannot be resolved the hook is a no-op.
+func HostnameHook() Hook {
+	hostname, _ := os.Hostname()
+	return HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+		if hostname != "" {
+			if _, exists := entry.Fields["hostname"]; !exists {
+				entry.Fields["hostname"] = hostname
+			}
+		}
+		return true
+	})
+}
+
+// EnvFieldsHook returns a hook that adds fields read from environment variables,
+// mapping field name to variable name. It is handy for Kubernetes metadata exposed
+// through the downward API. Variables are read once; unset or empty ones are skipped.
+//
+// Example:
+//
+//	loki.EnvFieldsHook(map[string]string{
+//		"pod":       "POD_NAME",
+//		"namespace": "POD_NAMESPACE",
+//		"node":      "NODE_NAME",
+//	})
+func EnvFieldsHook(vars map[string]string) Hook {
+	values := make(map[string]string, len(vars))
+	for field, env := range vars {
+		if v := os.Getenv(env); v != "" {
+			values[field] = v
+		}
+	}
+
+	return HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+		for field, v := range values {
+			if _, exists := entry.Fields[field]; !exists {
+				entry.Fields[field] = v
+			}
+		}
+		return true
+	})
+}
+
+// runHooks applies the configured hooks in order and reports whether the entry should be kept.
+func (l *Logger) runHooks(ctx context.Context, entry *types.Entry) bool {
+	for _, h := range l.config.Hooks {
+		if !h.Run(ctx, entry) {
+			return false
+		}
+		// Hooks may replace the maps; keep the invariants the rest of the pipeline relies on
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]any)
+		}
+		if entry.Labels == nil {
+			entry.Labels = make(types.Labels)
+		}
+	}
+	return true
+}
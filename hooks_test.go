@@ -0,0 +1,101 @@
+package loki
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/edaniel30/loki-logger-go/internal/mocks"
+	"github.com/edaniel30/loki-logger-go/internal/transport"
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+func TestLoggerHooks(t *testing.T) {
+	var order []string
+	cfg := newTestConfig()
+	cfg.Hooks = []Hook{
+		HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+			order = append(order, "first")
+			if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+				entry.Labels["tenant"] = tenant
+			}
+			entry.Fields["enriched"] = true
+			return true
+		}),
+		HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+			order = append(order, "second")
+			entry.Message = "[hooked] " + entry.Message
+			return entry.Fields["drop"] == nil
+		}),
+		HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+			order = append(order, "third")
+			entry.Fields = nil // replaced maps are restored
+			return true
+		}),
+	}
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	mock := mocks.NewMockTransport("mock")
+	logger.transports = []transport.Transport{mock}
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	logger.Info(ctx, "hello", nil)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, []string{"first", "second", "third"}, order)
+	assert.Equal(t, "[hooked] hello", entries[0].Message)
+	assert.Equal(t, "acme", entries[0].Labels["tenant"])
+	assert.NotNil(t, entries[0].Fields)
+
+	// Dropping an entry skips the remaining hooks and all transports
+	order = nil
+	mock.Reset()
+	logger.Info(ctx, "dropped", map[string]any{"drop": true})
+	assert.Empty(t, mock.GetEntries())
+	assert.Equal(t, []string{"first", "second"}, order)
+}
+
+func TestLoggerHooksRunBeforeRedaction(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Hooks = []Hook{HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+		entry.Fields["token"] = "abc"
+		return true
+	})}
+	cfg.Redaction = []RedactionRule{RedactCredentials()}
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	mock := mocks.NewMockTransport("mock")
+	logger.transports = []transport.Transport{mock}
+
+	logger.Info(context.Background(), "hello", nil)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "[REDACTED]", entries[0].Fields["token"])
+}
+
+func TestBuiltinHooks(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	entry := &types.Entry{Fields: map[string]any{}}
+	assert.True(t, HostnameHook().Run(context.Background(), entry))
+	assert.Equal(t, hostname, entry.Fields["hostname"])
+
+	t.Setenv("TEST_POD_NAME", "api-7d9f")
+	entry = &types.Entry{Fields: map[string]any{"namespace": "custom"}}
+	hook := EnvFieldsHook(map[string]string{
+		"pod":       "TEST_POD_NAME",
+		"namespace": "TEST_POD_NAMESPACE",
+		"node":      "TEST_UNSET_VARIABLE",
+	})
+	assert.True(t, hook.Run(context.Background(), entry))
+	assert.Equal(t, "api-7d9f", entry.Fields["pod"])
+	assert.Equal(t, "custom", entry.Fields["namespace"])
+	assert.NotContains(t, entry.Fields, "node")
+}
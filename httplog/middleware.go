@@ -0,0 +1,282 @@
+// Package httplog provides net/http middleware that writes access logs through a
+// loki.Logger and attaches a request-scoped child logger to every request context.
+package httplog
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	loki "github.com/edaniel30/loki-logger-go"
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+const (
+	// DefaultRequestIDHeader is the header used to read and propagate request IDs.
+	DefaultRequestIDHeader = "X-Request-ID"
+
+	// traceParentHeader is the W3C Trace Context header.
+	traceParentHeader = "traceparent"
+
+	// maxRequestIDLength is the maximum length of an incoming request ID.
+	maxRequestIDLength = 128
+)
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// Config configures the middleware.
+// Use functional options with Middleware to customize it.
+type Config struct {
+	// RequestIDHeader is the header an incoming request ID is read from and the
+	// response header it is echoed in (default: "X-Request-ID").
+	RequestIDHeader string
+
+	// TraceParent enables parsing the W3C "traceparent" header. The trace ID is added to
+	// the request logger as "trace_id" and the full trace context is stored in the request
+	// context for loki.W3CTraceContext (default: false).
+	TraceParent bool
+
+	// LevelFunc picks the access log level from the response status
+	// (default: 5xx Error, 4xx Warn, everything else Info).
+	LevelFunc func(status int) types.Level
+
+	// RouteFunc returns the route reported in the "route" field. It is called after the
+	// handler, so it can see the pattern matched by http.ServeMux
+	// (default: the matched pattern, or the URL path when there is none).
+	RouteFunc func(r *http.Request) string
+
+	// Message is the access log message (default: "http request").
+	Message string
+}
+
+// Option is a function that modifies a Config.
+type Option func(*Config)
+
+// WithRequestIDHeader sets the header used to read and propagate request IDs.
+func WithRequestIDHeader(header string) Option {
+	return func(c *Config) {
+		c.RequestIDHeader = header
+	}
+}
+
+// WithTraceParent enables or disables parsing the W3C "traceparent" header.
+func WithTraceParent(enabled bool) Option {
+	return func(c *Config) {
+		c.TraceParent = enabled
+	}
+}
+
+// WithLevelFunc sets the function that picks the access log level from the response status.
+func WithLevelFunc(fn func(status int) types.Level) Option {
+	return func(c *Config) {
+		c.LevelFunc = fn
+	}
+}
+
+// WithRouteFunc sets the function that returns the route reported in the "route" field.
+func WithRouteFunc(fn func(r *http.Request) string) Option {
+	return func(c *Config) {
+		c.RouteFunc = fn
+	}
+}
+
+// WithMessage sets the access log message.
+func WithMessage(message string) Option {
+	return func(c *Config) {
+		c.Message = message
+	}
+}
+
+// Middleware returns net/http middleware that logs one access entry per request with the
+// method, route, status, bytes written, duration and remote address.
+//
+// Each request gets a request ID, taken from the request ID header or generated, which is
+// echoed in the response. An incoming ID is only trusted when it has at most 128 printable
+// ASCII characters; otherwise a new one is generated. A child logger carrying "request_id" (and "trace_id" when
+// traceparent extraction is enabled) is stored in the request context; retrieve it with
+// loki.FromContext.
+//
+// A request whose handler panics is still logged, with status 500 unless the handler already
+// wrote a header and with a "panic" field; the panic is then propagated to net/http.
+// A handler aborted with http.ErrAbortHandler is logged with an "aborted" field instead and
+// the abort is propagated as is. The access log is written with the request context
+// values but without its cancellation, so requests whose client went away are logged too.
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+//		reqLogger, _ := loki.FromContext(r.Context())
+//		reqLogger.Info(r.Context(), "loading order", nil)
+//	})
+//	http.ListenAndServe(":8080", httplog.Middleware(logger, httplog.WithTraceParent(true))(mux))
+func Middleware(logger *loki.Logger, opts ...Option) func(http.Handler) http.Handler {
+	cfg := &Config{
+		RequestIDHeader: DefaultRequestIDHeader,
+		LevelFunc:       LevelForStatus,
+		RouteFunc:       defaultRoute,
+		Message:         "http request",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(cfg.RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(cfg.RequestIDHeader, requestID)
+
+			ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+
+			scoped := map[string]any{"request_id": requestID}
+			if cfg.TraceParent {
+				if tc, ok := loki.ParseTraceParent(r.Header.Get(traceParentHeader)); ok {
+					scoped["trace_id"] = tc.TraceID
+					ctx = loki.ContextWithTraceContext(ctx, tc)
+				}
+			}
+			reqLogger := logger.WithFields(scoped)
+
+			ctx = loki.NewContext(ctx, reqLogger)
+			r = r.WithContext(ctx)
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			// Log in a deferred call so that requests whose handler panics are logged too
+			defer func() {
+				fields := map[string]any{
+					"method":      r.Method,
+					"route":       cfg.RouteFunc(r),
+					"bytes":       rec.bytes,
+					"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+					"remote_addr": r.RemoteAddr,
+				}
+
+				// http.ErrAbortHandler aborts the response on purpose: it is not a crash
+				p := recover()
+				if p == http.ErrAbortHandler {
+					fields["aborted"] = true
+				} else if p != nil {
+					fields["panic"] = fmt.Sprint(p)
+					if !rec.wroteHeader {
+						rec.status = http.StatusInternalServerError
+					}
+				}
+				fields["status"] = rec.status
+
+				// The request context is canceled when the client goes away: keep its values only
+				reqLogger.Log(context.WithoutCancel(ctx), cfg.LevelFunc(rec.status), cfg.Message, fields)
+
+				if p != nil {
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// RequestIDFromContext returns the request ID assigned by the middleware, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// LevelForStatus is the default level mapping: 5xx Error, 4xx Warn, everything else Info.
+func LevelForStatus(status int) types.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return types.LevelError
+	case status >= http.StatusBadRequest:
+		return types.LevelWarn
+	default:
+		return types.LevelInfo
+	}
+}
+
+// defaultRoute returns the pattern matched by http.ServeMux, falling back to the URL path.
+func defaultRoute(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.URL.Path
+}
+
+// validRequestID reports whether an incoming request ID can be used as is: non-empty, at most
+// maxRequestIDLength bytes and only printable ASCII, so clients cannot inject control
+// characters or unbounded values into logs and response headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random 128-bit request ID encoded as hex.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read never returns an error
+	return hex.EncodeToString(b[:])
+}
+
+// responseRecorder captures the status code and number of bytes written.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher when the underlying writer supports it.
+func (rr *responseRecorder) Flush() {
+	if f, ok := rr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the underlying writer supports it, for WebSocket
+// upgrades and other protocols taking over the connection.
+func (rr *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("httplog: %T does not implement http.Hijacker", rr.ResponseWriter)
+	}
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
@@ -0,0 +1,276 @@
+package httplog
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	loki "github.com/edaniel30/loki-logger-go"
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capture collects entries through a hook and drops them so nothing is written to stdout.
+type capture struct {
+	mu      sync.Mutex
+	entries []*types.Entry
+	ctxErrs []error
+}
+
+func (c *capture) Run(ctx context.Context, entry *types.Entry) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+	c.ctxErrs = append(c.ctxErrs, ctx.Err())
+	return false
+}
+
+func (c *capture) get() []*types.Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*types.Entry(nil), c.entries...)
+}
+
+func newTestLogger(t *testing.T) (*loki.Logger, *capture) {
+	t.Helper()
+	c := &capture{}
+	logger, err := loki.New(loki.DefaultConfig(),
+		loki.WithOnlyConsole(true),
+		loki.WithLogLevel(types.LevelDebug),
+		loki.WithStackTraceLevels(),
+		loki.WithHooks(c),
+	)
+	require.NoError(t, err)
+	return logger, c
+}
+
+func TestMiddleware(t *testing.T) {
+	logger, c := newTestLogger(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		reqLogger, ok := loki.FromContext(r.Context())
+		require.True(t, ok)
+		reqLogger.Info(r.Context(), "loading order", nil)
+
+		id, ok := RequestIDFromContext(r.Context())
+		require.True(t, ok)
+		assert.Equal(t, "req-1", id)
+
+		tc, ok := loki.W3CTraceContext(r.Context())
+		require.True(t, ok)
+		assert.Equal(t, "00f067aa0ba902b7", tc.SpanID)
+
+		_, _ = w.Write([]byte("hello"))
+	})
+	handler := Middleware(logger, WithTraceParent(true))(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("traceparent", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-1", rec.Header().Get("X-Request-ID"))
+
+	entries := c.get()
+	require.Len(t, entries, 2)
+
+	// Entry written by the handler carries request-scoped fields
+	assert.Equal(t, "loading order", entries[0].Message)
+	assert.Equal(t, "req-1", entries[0].Fields["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entries[0].Fields["trace_id"])
+
+	access := entries[1]
+	assert.Equal(t, "http request", access.Message)
+	assert.Equal(t, types.LevelInfo, access.Level)
+	assert.Equal(t, "GET", access.Fields["method"])
+	assert.Equal(t, "GET /orders/{id}", access.Fields["route"])
+	assert.Equal(t, http.StatusOK, access.Fields["status"])
+	assert.Equal(t, 5, access.Fields["bytes"])
+	assert.Equal(t, "192.0.2.1:1234", access.Fields["remote_addr"])
+	assert.Contains(t, access.Fields, "duration_ms")
+	assert.Equal(t, "req-1", access.Fields["request_id"])
+}
+
+func TestMiddleware_Defaults(t *testing.T) {
+	logger, c := newTestLogger(t)
+
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/missing", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	entries := c.get()
+	require.Len(t, entries, 1)
+	access := entries[0]
+	assert.Equal(t, types.LevelWarn, access.Level)
+	assert.Equal(t, "/missing", access.Fields["route"])
+	assert.Equal(t, http.StatusNotFound, access.Fields["status"])
+	assert.NotContains(t, access.Fields, "trace_id") // extraction is opt-in
+
+	// A request ID is generated and echoed when the client did not send one
+	requestID := rec.Header().Get(DefaultRequestIDHeader)
+	assert.Len(t, requestID, 32)
+	assert.Equal(t, requestID, access.Fields["request_id"])
+}
+
+func TestMiddleware_InvalidRequestID(t *testing.T) {
+	logger, c := newTestLogger(t)
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, id := range []string{"req\nforged", "req\x1b[31m", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-ID", id)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		// The untrusted ID is replaced by a generated one
+		requestID := rec.Header().Get(DefaultRequestIDHeader)
+		assert.Len(t, requestID, 32)
+		assert.NotEqual(t, id, requestID)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", strings.Repeat("a", 128))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, strings.Repeat("a", 128), rec.Header().Get(DefaultRequestIDHeader))
+
+	assert.Len(t, c.get(), 4)
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	logger, c := newTestLogger(t)
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	// The panic still reaches net/http, after the request has been logged
+	assert.PanicsWithValue(t, "boom", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	})
+
+	entries := c.get()
+	require.Len(t, entries, 1)
+	assert.Equal(t, types.LevelError, entries[0].Level)
+	assert.Equal(t, http.StatusInternalServerError, entries[0].Fields["status"])
+	assert.Equal(t, "boom", entries[0].Fields["panic"])
+	assert.Equal(t, "/orders", entries[0].Fields["route"])
+}
+
+func TestMiddleware_AbortHandler(t *testing.T) {
+	logger, c := newTestLogger(t)
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic(http.ErrAbortHandler)
+	}))
+
+	// The abort reaches net/http unchanged and is not logged as a crash
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil))
+	})
+
+	entries := c.get()
+	require.Len(t, entries, 1)
+	assert.Equal(t, types.LevelInfo, entries[0].Level)
+	assert.Equal(t, http.StatusOK, entries[0].Fields["status"])
+	assert.Equal(t, true, entries[0].Fields["aborted"])
+	assert.NotContains(t, entries[0].Fields, "panic")
+}
+
+func TestMiddleware_CanceledRequest(t *testing.T) {
+	logger, c := newTestLogger(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel() // the client went away
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil).WithContext(ctx))
+
+	entries := c.get()
+	require.Len(t, entries, 1)
+	assert.NoError(t, c.ctxErrs[0])
+	assert.Equal(t, rec.Header().Get(DefaultRequestIDHeader), entries[0].Fields["request_id"])
+}
+
+func TestMiddleware_Options(t *testing.T) {
+	logger, c := newTestLogger(t)
+
+	handler := Middleware(logger,
+		WithRequestIDHeader("X-Correlation-ID"),
+		WithLevelFunc(func(int) types.Level { return types.LevelDebug }),
+		WithRouteFunc(func(*http.Request) string { return "custom" }),
+		WithMessage("access"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusOK) // superfluous, ignored
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-ID", "corr-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	entries := c.get()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "access", entries[0].Message)
+	assert.Equal(t, types.LevelDebug, entries[0].Level)
+	assert.Equal(t, "custom", entries[0].Fields["route"])
+	assert.Equal(t, http.StatusInternalServerError, entries[0].Fields["status"])
+	assert.Equal(t, "corr-1", entries[0].Fields["request_id"])
+	assert.Equal(t, "corr-1", rec.Header().Get("X-Correlation-ID"))
+}
+
+func TestLevelForStatus(t *testing.T) {
+	assert.Equal(t, types.LevelInfo, LevelForStatus(http.StatusOK))
+	assert.Equal(t, types.LevelInfo, LevelForStatus(http.StatusFound))
+	assert.Equal(t, types.LevelWarn, LevelForStatus(http.StatusBadRequest))
+	assert.Equal(t, types.LevelError, LevelForStatus(http.StatusServiceUnavailable))
+}
+
+func TestResponseRecorder(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rr := &responseRecorder{ResponseWriter: rec, status: http.StatusOK}
+
+	rr.Flush()
+	assert.True(t, rec.Flushed)
+	assert.Same(t, rec, rr.Unwrap())
+}
+
+// hijackableRecorder is a ResponseRecorder that supports http.Hijacker.
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseRecorder_Hijack(t *testing.T) {
+	// Not supported by the underlying writer
+	rr := &responseRecorder{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	_, _, err := rr.Hijack()
+	assert.Error(t, err)
+
+	hijackable := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rr = &responseRecorder{ResponseWriter: hijackable, status: http.StatusOK}
+	_, _, err = rr.Hijack()
+	require.NoError(t, err)
+	assert.True(t, hijackable.hijacked)
+
+	var _ http.Hijacker = rr
+}
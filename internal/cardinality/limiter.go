@@ -0,0 +1,52 @@
+package cardinality
+
+import "sync"
+
+// OverflowValue replaces label values beyond the limit.
+const OverflowValue = "__overflow__"
+
+// Limiter caps the number of distinct values tracked per label key.
+// Values seen before the limit was reached keep passing through unchanged;
+// new values beyond the limit are replaced with OverflowValue.
+// Limiter is safe for concurrent use.
+type Limiter struct {
+	max    int
+	values map[string]map[string]struct{}
+	warned map[string]bool
+	mu     sync.Mutex
+}
+
+// New creates a limiter allowing at most max distinct values per label key.
+func New(max int) *Limiter {
+	return &Limiter{
+		max:    max,
+		values: make(map[string]map[string]struct{}),
+		warned: make(map[string]bool),
+	}
+}
+
+// Value returns the label value to use for key. It reports overflow when the value
+// was replaced, and first when this is the first overflow for key.
+func (l *Limiter) Value(key, value string) (result string, overflow, first bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seen, exists := l.values[key]
+	if !exists {
+		seen = make(map[string]struct{})
+		l.values[key] = seen
+	}
+
+	if _, known := seen[value]; known {
+		return value, false, false
+	}
+
+	if len(seen) < l.max {
+		seen[value] = struct{}{}
+		return value, false, false
+	}
+
+	first = !l.warned[key]
+	l.warned[key] = true
+	return OverflowValue, true, first
+}
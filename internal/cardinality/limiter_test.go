@@ -0,0 +1,68 @@
+package cardinality
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(2)
+
+	v, overflow, first := l.Value("status_class", "2xx")
+	assert.Equal(t, "2xx", v)
+	assert.False(t, overflow)
+	assert.False(t, first)
+
+	v, overflow, _ = l.Value("status_class", "5xx")
+	assert.Equal(t, "5xx", v)
+	assert.False(t, overflow)
+
+	// Beyond the limit new values overflow, the first time is reported once
+	v, overflow, first = l.Value("status_class", "4xx")
+	assert.Equal(t, OverflowValue, v)
+	assert.True(t, overflow)
+	assert.True(t, first)
+
+	_, overflow, first = l.Value("status_class", "3xx")
+	assert.True(t, overflow)
+	assert.False(t, first)
+
+	// Known values keep passing through
+	v, overflow, _ = l.Value("status_class", "2xx")
+	assert.Equal(t, "2xx", v)
+	assert.False(t, overflow)
+
+	// Keys are tracked independently
+	v, overflow, _ = l.Value("region", "eu")
+	assert.Equal(t, "eu", v)
+	assert.False(t, overflow)
+}
+
+func TestLimiter_Concurrent(t *testing.T) {
+	l := New(10)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	firsts := 0
+	accepted := make(map[string]bool)
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, first := l.Value("user", fmt.Sprint(i))
+			mu.Lock()
+			defer mu.Unlock()
+			if first {
+				firsts++
+			}
+			accepted[v] = true
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, firsts)
+	assert.Len(t, accepted, 11) // 10 distinct values plus the overflow value
+}
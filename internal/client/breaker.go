@@ -0,0 +1,163 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Push when the circuit breaker rejects the request without contacting Loki.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every request through.
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen rejects every request until the open timeout elapses.
+	BreakerOpen
+
+	// BreakerHalfOpen lets a single probe request through to test whether Loki recovered.
+	BreakerHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig configures a circuit breaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed requests that opens the circuit
+	FailureThreshold int
+
+	// OpenTimeout is how long the circuit stays open before a probe request is allowed
+	OpenTimeout time.Duration
+
+	// OnStateChange is an optional callback invoked on every state transition.
+	// It is called synchronously once the pushing goroutine released its locks, so it may
+	// log through a logger that pushes through this breaker, and must not block.
+	OnStateChange func(from, to BreakerState)
+}
+
+// breaker is a consecutive-failure circuit breaker. It is safe for concurrent use.
+type breaker struct {
+	config   BreakerConfig
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool          // a half-open probe is in flight
+	pending  []stateChange // transitions not reported to OnStateChange yet
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+func newBreaker(config BreakerConfig) *breaker {
+	return &breaker{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// allow reports whether a request may be sent. In half-open state only one probe is allowed at a time.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.config.OpenTimeout {
+			return false
+		}
+		b.transition(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success records a successful request and closes the circuit.
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != BreakerClosed {
+		b.transition(BreakerClosed)
+	}
+}
+
+// failure records a failed request, opening the circuit when the threshold is reached
+// or when a half-open probe fails.
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.config.FailureThreshold) {
+		b.openedAt = b.now()
+		b.transition(BreakerOpen)
+	}
+}
+
+// rejecting reports whether allow would currently refuse every request: the circuit is
+// open and its timeout has not elapsed. Unlike allow, it never starts a probe.
+func (b *breaker) rejecting() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == BreakerOpen && b.now().Sub(b.openedAt) < b.config.OpenTimeout
+}
+
+// State returns the current state.
+func (b *breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// stateChange is a state transition waiting to be reported to OnStateChange.
+type stateChange struct {
+	from, to BreakerState
+}
+
+// transition changes the state and queues the change for notify. Must be called with mu held.
+func (b *breaker) transition(to BreakerState) {
+	if b.config.OnStateChange != nil {
+		b.pending = append(b.pending, stateChange{from: b.state, to: to})
+	}
+	b.state = to
+}
+
+// notify reports the queued transitions to OnStateChange. It must be called without mu
+// or any lock of the pushing goroutine held, since the callback may log through a logger
+// that pushes through this breaker again.
+func (b *breaker) notify() {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	for _, change := range pending {
+		b.config.OnStateChange(change.from, change.to)
+	}
+}
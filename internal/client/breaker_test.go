@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	var transitions []string
+	b := newBreaker(BreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		OnStateChange: func(from, to BreakerState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	// Closed: failures below the threshold keep it closed, a success resets the count
+	assert.True(t, b.allow())
+	b.failure()
+	b.success()
+	b.failure()
+	assert.Equal(t, BreakerClosed, b.State())
+
+	// Reaching the threshold opens it
+	b.failure()
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.False(t, b.allow())
+
+	// After the timeout a single probe is allowed
+	now = now.Add(time.Minute)
+	assert.True(t, b.allow())
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.False(t, b.allow())
+
+	// A failed probe reopens it
+	b.failure()
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.False(t, b.allow())
+
+	// A successful probe closes it
+	now = now.Add(time.Minute)
+	assert.True(t, b.allow())
+	b.success()
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.True(t, b.allow())
+
+	// Transitions are reported when notify is called, in order
+	assert.Empty(t, transitions)
+	b.notify()
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, transitions)
+	assert.Equal(t, "unknown", BreakerState(99).String())
+}
+
+func TestClient_PushCircuitBreaker(t *testing.T) {
+	var requests atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "", "", 10*time.Second, 5)
+	c.SetBreaker(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Hour})
+	entries := []*types.Entry{{Level: types.LevelInfo, Message: "test", Timestamp: time.Now(), Labels: types.Labels{"app": "test"}}}
+
+	// The breaker opens after two attempts and cuts the remaining retries short
+	err := c.Push(context.Background(), entries)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Contains(t, err.Error(), "503")
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, BreakerOpen, c.BreakerState())
+
+	// While open, Push fails fast without contacting Loki
+	err = c.Push(context.Background(), entries)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// Loki answering with a client error is reachable and does not trip the breaker
+	c = NewClient(server.URL, "", "", 10*time.Second, 2)
+	c.SetBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour})
+	status.Store(http.StatusUnauthorized)
+	err = c.Push(context.Background(), entries)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, BreakerClosed, c.BreakerState())
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+}
@@ -4,15 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
 	"net/http"
-	"sort"
-	"strconv"
+	"sync"
 	"time"
 
 	"github.com/edaniel30/loki-logger-go/internal/client/models"
+	"github.com/edaniel30/loki-logger-go/internal/metrics"
 	"github.com/edaniel30/loki-logger-go/types"
 )
 
@@ -20,6 +21,9 @@ const (
 	// lokiPushEndpoint is the API endpoint for pushing logs to Loki
 	lokiPushEndpoint = "/loki/api/v1/push"
 
+	// lokiReadyEndpoint is the readiness endpoint of the Loki server
+	lokiReadyEndpoint = "/ready"
+
 	// initialBackoffMS is the initial backoff duration for retries in milliseconds
 	initialBackoffMS = 100
 
@@ -34,6 +38,42 @@ type Client struct {
 	password   string
 	httpClient *http.Client
 	maxRetries int
+	metrics    *metrics.Transport // nil when metrics are not collected
+	breaker    *breaker           // nil when the circuit breaker is disabled
+
+	metadataKeys map[string]struct{} // fields sent as structured metadata instead of in the body
+	maxLineSize  int                 // 0 disables the line size limit
+	lineStrategy LineStrategy
+
+	maxBatchBytes int // 0 sends every batch in a single request
+
+	onRejected func(entries []*types.Entry, err error) // nil when rejected entries are only dropped
+
+	strictTimestamps bool
+	lastTimestamps   map[string]int64 // last timestamp accepted by Loki per stream, with strict timestamps
+	mu               sync.Mutex       // guards lastTimestamps
+	pushMu           sync.Mutex       // serializes pushes with strict timestamps
+}
+
+// StatusError is returned when Loki responds with a non-2xx status code.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("loki returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// isServerFailure reports whether err means Loki could not be reached or could not
+// handle the request, as opposed to rejecting its content. Only server failures
+// count towards opening the circuit breaker.
+func isServerFailure(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
 }
 
 // NewClient creates a new Loki HTTP client.
@@ -49,72 +89,295 @@ func NewClient(baseURL string, username string, password string, timeout time.Du
 	}
 }
 
+// SetMetrics sets the counters updated by Push. It must be called before the client is used.
+func (c *Client) SetMetrics(m *metrics.Transport) {
+	c.metrics = m
+}
+
+// SetMetadataKeys makes the given field keys be sent as structured metadata
+// instead of in the JSON log line. It must be called before the client is used.
+func (c *Client) SetMetadataKeys(keys []string) {
+	c.metadataKeys = make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		c.metadataKeys[key] = struct{}{}
+	}
+}
+
+// SetMaxBatchBytes splits pushes whose payload exceeds maxBytes into several requests.
+// A value of 0 disables splitting. It must be called before the client is used.
+func (c *Client) SetMaxBatchBytes(maxBytes int) {
+	c.maxBatchBytes = maxBytes
+}
+
+// SetOnRejected sets a callback receiving the entries Loki rejected with a 400 status,
+// along with the error returned for them. It must be called before the client is used.
+func (c *Client) SetOnRejected(fn func(entries []*types.Entry, err error)) {
+	c.onRejected = fn
+}
+
+// SetBreaker enables a circuit breaker around Push. While the circuit is open Push
+// fails fast with ErrCircuitOpen. It must be called before the client is used.
+func (c *Client) SetBreaker(config BreakerConfig) {
+	c.breaker = newBreaker(config)
+}
+
+// BreakerState returns the state of the circuit breaker, or BreakerClosed if it is disabled.
+func (c *Client) BreakerState() BreakerState {
+	if c.breaker == nil {
+		return BreakerClosed
+	}
+	return c.breaker.State()
+}
+
+// CircuitOpen reports whether Push currently fails fast with ErrCircuitOpen,
+// so callers can skip building pushes that would be rejected.
+func (c *Client) CircuitOpen() bool {
+	return c.breaker != nil && c.breaker.rejecting()
+}
+
 // Push sends log entries to Loki with automatic retries.
+// Entries are split into several requests when their payload exceeds the maximum batch size;
+// if only some of them fail, a *PartialError listing the entries that were not pushed is returned.
+// When Loki rejects the content of a request, the entries it rejected are isolated and
+// reported to the callback set with SetOnRejected, and the others are pushed.
+// It returns an error wrapping ErrCircuitOpen if the circuit breaker rejected the entries.
 func (c *Client) Push(ctx context.Context, entries []*types.Entry) error {
 	if len(entries) == 0 {
 		return nil
 	}
 
-	payload, err := c.buildPayload(entries)
+	// Report breaker transitions once every lock below is released
+	if c.breaker != nil {
+		defer c.breaker.notify()
+	}
+
+	// Requests must reach Loki in the order their timestamps were assigned
+	if c.strictTimestamps {
+		c.pushMu.Lock()
+		defer c.pushMu.Unlock()
+	}
+
+	if c.breaker != nil && !c.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	encoded, err := c.encodeEntries(entries)
+	var batches []batch
+	if err == nil {
+		batches, err = c.buildBatches(encoded)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to build payload: %w", err)
+		err = fmt.Errorf("failed to build payload: %w", err)
+		if c.breaker != nil {
+			c.breaker.success() // nothing was sent, release a half-open probe
+		}
+		if c.metrics != nil {
+			c.metrics.Batches.Inc()
+			c.metrics.PushErrors.Inc()
+			c.metrics.PushFailed(err)
+		}
+		return err
 	}
 
-	return c.sendWithRetry(ctx, payload)
+	var firstErr error
+	var failed []*types.Entry
+	for i, b := range batches {
+		// The first request was allowed above
+		if i > 0 && c.breaker != nil && !c.breaker.allow() {
+			err = ErrCircuitOpen
+		} else {
+			err = c.pushBatch(ctx, b)
+		}
+
+		if err != nil && isRejection(err) && ctx.Err() == nil {
+			err = c.isolateRejected(ctx, b.entries, err, 0)
+		}
+
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed = append(failed, sourceEntries(b.entries)...)
+		}
+	}
+
+	if firstErr != nil && len(failed) < len(entries) {
+		return &PartialError{Failed: failed, Err: firstErr}
+	}
+	return firstErr
 }
 
-// buildPayload constructs the JSON payload expected by Loki's push API.
-func (c *Client) buildPayload(entries []*types.Entry) ([]byte, error) {
-	// Group entries by label set
-	streams := make(map[string]*models.Stream)
+// PartialError is returned by Push when entries were split into several requests
+// and only some of them failed.
+type PartialError struct {
+	Failed []*types.Entry // Entries that were not pushed
+	Err    error          // Error of the first failed request
+}
 
-	for _, entry := range entries {
-		labelKey := c.labelsToKey(entry.Labels)
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("failed to push %d entries: %v", len(e.Failed), e.Err)
+}
 
-		s, exists := streams[labelKey]
-		if !exists {
-			s = &models.Stream{
-				Stream: entry.Labels,
-				Values: make([][]string, 0),
-			}
-			streams[labelKey] = s
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// batch is a group of entries sent in a single push request.
+type batch struct {
+	entries []*encodedEntry
+	payload []byte
+}
+
+// encodedEntry is an entry formatted as the values of its stream. Entries are encoded once
+// per push and every request sending them reuses the same lines and timestamps, so that
+// an entry sent again after a split or while isolating rejected entries is identical.
+type encodedEntry struct {
+	entry  *types.Entry
+	stream string // key of the entry labels
+	values []timestampedValue
+}
+
+// sourceEntries returns the entries that were encoded.
+func sourceEntries(encoded []*encodedEntry) []*types.Entry {
+	entries := make([]*types.Entry, len(encoded))
+	for i, e := range encoded {
+		entries[i] = e.entry
+	}
+	return entries
+}
+
+// buildBatches encodes entries in payloads of at most maxBatchBytes, halving the
+// entries until each payload fits. An entry larger than the limit is sent on its own.
+func (c *Client) buildBatches(entries []*encodedEntry) ([]batch, error) {
+	payload, err := c.encodePayload(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.maxBatchBytes <= 0 || len(payload) <= c.maxBatchBytes || len(entries) == 1 {
+		return []batch{{entries: entries, payload: payload}}, nil
+	}
+
+	mid := len(entries) / 2
+	first, err := c.buildBatches(entries[:mid])
+	if err != nil {
+		return nil, err
+	}
+	rest, err := c.buildBatches(entries[mid:])
+	if err != nil {
+		return nil, err
+	}
+
+	return append(first, rest...), nil
+}
+
+// pushBatch sends a single request and updates the push metrics.
+func (c *Client) pushBatch(ctx context.Context, b batch) error {
+	if c.metrics != nil {
+		c.metrics.Batches.Inc()
+	}
+
+	err := c.sendWithRetry(ctx, b.payload)
+
+	// Rejected content is accounted for once the rejected entries are isolated
+	if c.metrics != nil && !isRejection(err) {
+		if err != nil {
+			c.metrics.PushErrors.Inc()
+			c.metrics.PushFailed(err)
+		} else {
+			c.metrics.Pushed.Add(uint64(len(b.entries)))
+			c.metrics.PushSucceeded()
 		}
+	}
+
+	if err == nil {
+		c.markSent(b.entries)
+	}
 
-		// Format entry as JSON for the log line
-		logLine, err := c.formatLogLine(entry)
+	return err
+}
+
+// encodeEntries formats every entry as the values of its stream.
+// With strict timestamps, entries are also sorted by timestamp.
+func (c *Client) encodeEntries(entries []*types.Entry) ([]*encodedEntry, error) {
+	encoded := make([]*encodedEntry, len(entries))
+
+	for i, entry := range entries {
+		// Format entry as JSON for the log line, split in several lines if it is too long
+		logLines, err := c.formatLogLines(entry)
 		if err != nil {
 			return nil, err
 		}
 
-		// Loki expects [timestamp_nanoseconds, log_line]
-		timestamp := strconv.FormatInt(entry.Timestamp.UnixNano(), 10)
-		s.Values = append(s.Values, []string{timestamp, logLine})
+		// Loki expects [timestamp_nanoseconds, log_line(, structured_metadata)].
+		// Continuation lines are 1ns apart so that they keep their order.
+		metadata := c.structuredMetadata(entry)
+		values := make([]timestampedValue, len(logLines))
+		for j, logLine := range logLines {
+			values[j] = timestampedValue{
+				ns:    entry.Timestamp.UnixNano() + int64(j),
+				value: models.Value{Line: logLine, Metadata: metadata},
+			}
+		}
+
+		encoded[i] = &encodedEntry{entry: entry, stream: c.labelsToKey(entry.Labels), values: values}
+	}
+
+	if c.strictTimestamps {
+		c.enforceIncreasing(encoded)
+	}
+
+	return encoded, nil
+}
+
+// encodePayload groups encoded entries by label set in the JSON payload expected by Loki's push API.
+func (c *Client) encodePayload(entries []*encodedEntry) ([]byte, error) {
+	streams := make(map[string]types.Labels)
+	values := make(map[string][]timestampedValue)
+
+	for _, e := range entries {
+		if _, exists := streams[e.stream]; !exists {
+			streams[e.stream] = e.entry.Labels
+		}
+		values[e.stream] = append(values[e.stream], e.values...)
 	}
 
-	// Build final payload
+	// Build final payload, with the values of each stream in timestamp order:
+	// entries logged concurrently are not always buffered in the order they were created
 	payload := models.PushRequest{
 		Streams: make([]*models.Stream, 0, len(streams)),
 	}
 
-	for _, s := range streams {
-		payload.Streams = append(payload.Streams, s)
+	for labelKey, labels := range streams {
+		payload.Streams = append(payload.Streams, &models.Stream{
+			Stream: labels,
+			Values: orderValues(values[labelKey]),
+		})
 	}
 
 	return json.Marshal(payload)
 }
 
-// formatLogLine converts an entry to a JSON log line.
-// The message and user fields are included in the JSON body.
+// lineData returns the message and fields making up the JSON log line of an entry.
 // System labels (app, level, version, environment) are already in Loki labels and excluded from the body.
-func (c *Client) formatLogLine(entry *types.Entry) (string, error) {
-	buf := Get()
-	defer Put(buf)
-
-	data := make(map[string]any)
+func (c *Client) lineData(entry *types.Entry) map[string]any {
+	data := make(map[string]any, len(entry.Fields)+1)
 	data["message"] = entry.Message
 
-	// Add all custom fields (user-provided data)
-	maps.Copy(data, entry.Fields)
+	// Add all custom fields (user-provided data) except those sent as structured metadata
+	for k, v := range entry.Fields {
+		if _, isMetadata := c.metadataKeys[k]; !isMetadata {
+			data[k] = v
+		}
+	}
+
+	return data
+}
+
+// encodeLine encodes the data of a log line as JSON.
+func encodeLine(data map[string]any) (string, error) {
+	buf := Get()
+	defer Put(buf)
 
 	encoder := json.NewEncoder(buf)
 	if err := encoder.Encode(data); err != nil {
@@ -130,33 +393,38 @@ func (c *Client) formatLogLine(entry *types.Entry) (string, error) {
 	return line, nil
 }
 
-// labelsToKey creates a unique key from labels for grouping.
-// Keys are sorted alphabetically to ensure deterministic ordering,
-// since Go map iteration order is non-deterministic.
-func (c *Client) labelsToKey(labels map[string]string) string {
-	if len(labels) == 0 {
-		return ""
+// structuredMetadata merges the entry's metadata with the fields configured as metadata keys.
+// Non-string field values are formatted with fmt.Sprint. Entry metadata takes precedence.
+func (c *Client) structuredMetadata(entry *types.Entry) map[string]string {
+	if len(entry.Metadata) == 0 && len(c.metadataKeys) == 0 {
+		return nil
 	}
 
-	buf := Get()
-	defer Put(buf)
-
-	// Extract and sort keys for deterministic ordering
-	keys := make([]string, 0, len(labels))
-	for k := range labels {
-		keys = append(keys, k)
+	metadata := make(map[string]string, len(entry.Metadata))
+	for k := range c.metadataKeys {
+		v, exists := entry.Fields[k]
+		if !exists {
+			continue
+		}
+		if str, ok := v.(string); ok {
+			metadata[k] = str
+		} else {
+			metadata[k] = fmt.Sprint(v)
+		}
 	}
-	sort.Strings(keys)
+	maps.Copy(metadata, entry.Metadata)
 
-	// Build key string with sorted labels
-	for _, k := range keys {
-		buf.WriteString(k)
-		buf.WriteString("=")
-		buf.WriteString(labels[k])
-		buf.WriteString(";")
+	if len(metadata) == 0 {
+		return nil
 	}
+	return metadata
+}
 
-	return buf.String()
+// labelsToKey creates a unique key from labels for grouping.
+// It delegates to types.Labels.Key so that every stage keyed by stream
+// (batching, rate limiting) agrees on the same identity.
+func (c *Client) labelsToKey(labels map[string]string) string {
+	return types.Labels(labels).Key()
 }
 
 // sendWithRetry attempts to send the payload with exponential backoff.
@@ -172,9 +440,36 @@ func (c *Client) sendWithRetry(ctx context.Context, payload []byte) error {
 			case <-ctx.Done():
 				return ctx.Err()
 			}
+
+			// Stop retrying once the breaker has opened, whoever tripped it
+			if c.breaker != nil && c.breaker.State() != BreakerClosed {
+				return fmt.Errorf("%w after %d attempts: %w", ErrCircuitOpen, attempt, lastErr)
+			}
+
+			if c.metrics != nil {
+				c.metrics.Retries.Inc()
+			}
+		}
+
+		start := time.Now()
+		err := c.send(ctx, payload)
+		if c.metrics != nil {
+			c.metrics.ObservePush(time.Since(start))
 		}
 
-		if err := c.send(ctx, payload); err != nil {
+		if c.breaker != nil {
+			if err != nil && isServerFailure(err) {
+				c.breaker.failure()
+			} else {
+				c.breaker.success()
+			}
+		}
+
+		if err != nil {
+			// Loki rejected the content: sending it again will not help
+			if !isServerFailure(err) {
+				return err
+			}
 			lastErr = err
 			continue
 		}
@@ -214,7 +509,32 @@ func (c *Client) send(ctx context.Context, payload []byte) error {
 		if err != nil {
 			return fmt.Errorf("loki returned status %d (failed to read response body: %w)", resp.StatusCode, err)
 		}
-		return fmt.Errorf("loki returned status %d: %s", resp.StatusCode, string(body))
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// Ready checks Loki's readiness endpoint and returns an error if the server is not ready.
+func (c *Client) Ready(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+lokiReadyEndpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("loki is not ready: status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
 	}
 
 	return nil
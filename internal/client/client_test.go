@@ -1,56 +1,24 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/edaniel30/loki-logger-go/internal/client/models"
+	"github.com/edaniel30/loki-logger-go/internal/metrics"
 	"github.com/edaniel30/loki-logger-go/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestClient_formatLogLine(t *testing.T) {
-	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
-
-	entry := &types.Entry{
-		Level:   types.LevelInfo,
-		Message: "test message",
-		Fields:  map[string]any{},
-	}
-	line, err := c.formatLogLine(entry)
-	require.NoError(t, err)
-
-	var data map[string]any
-	err = json.Unmarshal([]byte(line), &data)
-	require.NoError(t, err)
-	assert.Equal(t, "test message", data["message"])
-	// level is now only in Loki labels, not in the JSON body
-	assert.Nil(t, data["level"])
-
-	entry = &types.Entry{
-		Level:   types.LevelError,
-		Message: "error occurred",
-		Fields: map[string]any{
-			"user_id": 123,
-			"action":  "login",
-		},
-	}
-	line, err = c.formatLogLine(entry)
-	require.NoError(t, err)
-
-	err = json.Unmarshal([]byte(line), &data)
-	require.NoError(t, err)
-	assert.Equal(t, "error occurred", data["message"])
-	assert.Equal(t, float64(123), data["user_id"])
-	assert.Equal(t, "login", data["action"])
-	// level is now only in Loki labels, not in the JSON body
-	assert.Nil(t, data["level"])
-}
-
 func TestClient_labelsToKey(t *testing.T) {
 	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
 
@@ -69,10 +37,26 @@ func TestClient_labelsToKey(t *testing.T) {
 	assert.Equal(t, key1, key2)
 }
 
+// buildPayload encodes entries the way Push does and returns the payload of the single batch.
+func buildPayload(t *testing.T, c *Client, entries []*types.Entry) ([]byte, error) {
+	t.Helper()
+
+	encoded, err := c.encodeEntries(entries)
+	if err != nil {
+		return nil, err
+	}
+	batches, err := c.buildBatches(encoded)
+	if err != nil {
+		return nil, err
+	}
+	require.Len(t, batches, 1)
+	return batches[0].payload, nil
+}
+
 func TestClient_buildPayload(t *testing.T) {
 	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
 
-	payload, err := c.buildPayload([]*types.Entry{})
+	payload, err := buildPayload(t, c, []*types.Entry{})
 	require.NoError(t, err)
 	assert.NotNil(t, payload)
 
@@ -83,7 +67,7 @@ func TestClient_buildPayload(t *testing.T) {
 		Labels:    types.Labels{"app": "test"},
 		Fields:    map[string]any{"key": "value"},
 	}
-	payload, err = c.buildPayload([]*types.Entry{entry})
+	payload, err = buildPayload(t, c, []*types.Entry{entry})
 	require.NoError(t, err)
 
 	var data map[string]any
@@ -135,7 +119,7 @@ func TestClient_buildPayload(t *testing.T) {
 		Fields:    map[string]any{},
 	}
 
-	payload, err = c.buildPayload([]*types.Entry{entry1, entry2, entry3})
+	payload, err = buildPayload(t, c, []*types.Entry{entry1, entry2, entry3})
 	require.NoError(t, err)
 
 	err = json.Unmarshal(payload, &data)
@@ -145,6 +129,61 @@ func TestClient_buildPayload(t *testing.T) {
 	assert.Equal(t, 2, len(streams)) // 2 streams: prod and dev
 }
 
+func TestClient_buildPayloadStructuredMetadata(t *testing.T) {
+	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
+	c.SetMetadataKeys([]string{"trace_id", "user_id"})
+
+	withMetadata := &types.Entry{
+		Level:     types.LevelInfo,
+		Message:   "order created",
+		Timestamp: time.Unix(1000, 0),
+		Labels:    types.Labels{"app": "test"},
+		Fields:    map[string]any{"trace_id": "abc123", "user_id": 42, "order": "o-1"},
+		Metadata:  map[string]string{"tenant": "acme", "user_id": "override"},
+	}
+	plain := &types.Entry{
+		Level:     types.LevelInfo,
+		Message:   "no metadata",
+		Timestamp: time.Unix(1001, 0),
+		Labels:    types.Labels{"app": "test"},
+		Fields:    map[string]any{"order": "o-2"},
+	}
+
+	payload, err := buildPayload(t, c, []*types.Entry{withMetadata, plain})
+	require.NoError(t, err)
+
+	// Lines without metadata keep the two-element form for older Loki versions
+	assert.Contains(t, string(payload), `["1001000000000","{\"message\":\"no metadata\",\"order\":\"o-2\"}"]`)
+
+	var req models.PushRequest
+	require.NoError(t, json.Unmarshal(payload, &req))
+	require.Len(t, req.Streams, 1)
+	require.Len(t, req.Streams[0].Values, 2)
+
+	value := req.Streams[0].Values[0]
+	assert.Equal(t, "1000000000000", value.Timestamp)
+	assert.Equal(t, map[string]string{"trace_id": "abc123", "user_id": "override", "tenant": "acme"}, value.Metadata)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(value.Line), &line))
+	assert.Equal(t, map[string]any{"message": "order created", "order": "o-1"}, line)
+
+	assert.Nil(t, req.Streams[0].Values[1].Metadata)
+}
+
+func TestValueUnmarshalJSON(t *testing.T) {
+	var v models.Value
+	require.NoError(t, json.Unmarshal([]byte(`["1","line"]`), &v))
+	assert.Equal(t, models.Value{Timestamp: "1", Line: "line"}, v)
+
+	require.NoError(t, json.Unmarshal([]byte(`["2","line",{"k":"v"}]`), &v))
+	assert.Equal(t, map[string]string{"k": "v"}, v.Metadata)
+
+	assert.Error(t, json.Unmarshal([]byte(`["1"]`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"ts":"1"}`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`[1,"line"]`), &v))
+}
+
 func TestClient_Push(t *testing.T) {
 	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
 	err := c.Push(context.Background(), []*types.Entry{})
@@ -230,4 +269,169 @@ func TestClient_sendWithRetry(t *testing.T) {
 	err = c.sendWithRetry(context.Background(), []byte(`{"test":"data"}`))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed after 2 retries")
+
+	// Test 4xx responses other than 429 are not retried: Loki refused the request itself
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusRequestEntityTooLarge} {
+		attempts = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(status)
+		}))
+
+		c = NewClient(server.URL, "", "", 10*time.Second, 2)
+		err = c.sendWithRetry(context.Background(), []byte(`{"test":"data"}`))
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, status, statusErr.StatusCode)
+		assert.Equal(t, 1, attempts, "status %d", status)
+		server.Close()
+	}
+
+	// Test 429 is retried
+	attempts = 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	c = NewClient(server.URL, "", "", 10*time.Second, 2)
+	err = c.sendWithRetry(context.Background(), []byte(`{"test":"data"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestClient_PushMetrics(t *testing.T) {
+	attempts := 0
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 || failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	m := &metrics.Transport{PushLatency: metrics.NewHistogram(metrics.DefaultBuckets)}
+	c := NewClient(server.URL, "", "", 10*time.Second, 1)
+	c.SetMetrics(m)
+
+	entries := []*types.Entry{
+		{Level: types.LevelInfo, Message: "one", Timestamp: time.Now(), Labels: types.Labels{"app": "test"}},
+		{Level: types.LevelInfo, Message: "two", Timestamp: time.Now(), Labels: types.Labels{"app": "test"}},
+	}
+
+	// First attempt fails, the retry succeeds
+	require.NoError(t, c.Push(context.Background(), entries))
+	assert.Equal(t, uint64(1), m.Batches.Load())
+	assert.Equal(t, uint64(2), m.Pushed.Load())
+	assert.Equal(t, uint64(1), m.Retries.Load())
+	_, _, _, observed := m.PushLatency.Snapshot()
+	assert.Equal(t, uint64(2), observed)
+
+	// Both attempts fail
+	failing = true
+	require.Error(t, c.Push(context.Background(), entries))
+	assert.Equal(t, uint64(2), m.Batches.Load())
+	assert.Equal(t, uint64(1), m.PushErrors.Load())
+}
+
+func TestClient_PushMaxBatchBytes(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	pushed := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("poison")) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var req models.PushRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+
+		mu.Lock()
+		defer mu.Unlock()
+		sizes = append(sizes, len(body))
+		for _, s := range req.Streams {
+			pushed += len(s.Values)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	m := &metrics.Transport{PushLatency: metrics.NewHistogram(metrics.DefaultBuckets)}
+	c := NewClient(server.URL, "", "", 10*time.Second, 0)
+	c.SetMetrics(m)
+	c.SetMaxBatchBytes(500)
+
+	entries := make([]*types.Entry, 10)
+	for i := range entries {
+		entries[i] = &types.Entry{
+			Level:     types.LevelInfo,
+			Message:   strings.Repeat("x", 100),
+			Timestamp: time.Now(),
+			Labels:    types.Labels{"app": "test"},
+		}
+	}
+
+	require.NoError(t, c.Push(context.Background(), entries))
+	assert.Equal(t, 10, pushed)
+	assert.Greater(t, len(sizes), 2)
+	for _, size := range sizes {
+		assert.LessOrEqual(t, size, 500)
+	}
+	assert.Equal(t, uint64(len(sizes)), m.Batches.Load())
+	assert.Equal(t, uint64(10), m.Pushed.Load())
+
+	// Only the entries of the failed request are reported
+	entries[9] = &types.Entry{Message: "poison", Timestamp: time.Now(), Labels: types.Labels{"app": "test"}}
+	err := c.Push(context.Background(), entries)
+
+	var partialErr *PartialError
+	require.ErrorAs(t, err, &partialErr)
+	assert.Contains(t, partialErr.Failed, entries[9])
+	assert.Less(t, len(partialErr.Failed), len(entries))
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+
+	// Every request failed: the error is returned as is
+	for _, entry := range entries {
+		entry.Message = "poison"
+	}
+	err = c.Push(context.Background(), entries)
+	require.Error(t, err)
+	assert.NotErrorAs(t, err, &partialErr)
+}
+
+func TestClient_Ready(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ready", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "", "", 10*time.Second, 3)
+	assert.NoError(t, c.Ready(context.Background()))
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("Ingester not ready: waiting for 15s after being ready\n"))
+	}))
+	defer server.Close()
+
+	c = NewClient(server.URL, "", "", 10*time.Second, 3)
+	err := c.Ready(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "loki is not ready: status 503: Ingester not ready: waiting for 15s after being ready", err.Error())
+
+	c = NewClient("http://127.0.0.1:0", "", "", time.Second, 0)
+	assert.Error(t, c.Ready(context.Background()))
 }
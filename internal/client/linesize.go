@@ -0,0 +1,232 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"unicode/utf8"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// LineStrategy determines how log lines longer than the maximum line size are shortened.
+// Loki rejects the whole push with a 400 when a single line exceeds its max_line_size.
+type LineStrategy int
+
+const (
+	// LineTruncate cuts the message, then the largest field values if that is not enough.
+	LineTruncate LineStrategy = iota
+	// LineTrimFields cuts the largest field values first, then the message.
+	LineTrimFields
+	// LineSplit spreads the message over continuation lines sharing a split ID.
+	// Fields are only kept on the first line.
+	LineSplit
+)
+
+const (
+	// FieldTruncated is set to true on lines shortened to fit the maximum line size.
+	FieldTruncated = "truncated"
+	// FieldSplitID is the ID shared by all the lines an entry was split into.
+	FieldSplitID = "split_id"
+	// FieldSplitPart is the 1-based position of a line among the lines of a split entry.
+	FieldSplitPart = "split_part"
+	// FieldSplitParts is the number of lines a split entry was split into.
+	FieldSplitParts = "split_parts"
+)
+
+// SetLineLimit enforces a maximum log line size in bytes using the given strategy.
+// A size of 0 disables the limit. It must be called before the client is used.
+func (c *Client) SetLineLimit(maxSize int, strategy LineStrategy) {
+	c.maxLineSize = maxSize
+	c.lineStrategy = strategy
+}
+
+// formatLogLines formats an entry as a single log line, shortened if it exceeds the
+// maximum line size, or as several lines with LineSplit.
+func (c *Client) formatLogLines(entry *types.Entry) ([]string, error) {
+	data := c.lineData(entry)
+
+	line, err := encodeLine(data)
+	if err != nil || c.maxLineSize <= 0 || len(line) <= c.maxLineSize {
+		return []string{line}, err
+	}
+
+	switch c.lineStrategy {
+	case LineSplit:
+		return c.splitLine(data, splitID(entry))
+	case LineTrimFields:
+		line, err = c.shrinkLine(data, c.maxLineSize, true)
+	default:
+		line, err = c.shrinkLine(data, c.maxLineSize, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{line}, nil
+}
+
+// shrinkLine marks the line as truncated and cuts the message and the largest field
+// values until the encoded line fits in limit bytes. With fieldsFirst, fields are cut
+// before the message. Non-string field values are cut as their JSON representation.
+// The line is returned as is when nothing is left to cut.
+func (c *Client) shrinkLine(data map[string]any, limit int, fieldsFirst bool) (string, error) {
+	data[FieldTruncated] = true
+
+	for {
+		line, err := encodeLine(data)
+		if err != nil || len(line) <= limit {
+			return line, err
+		}
+
+		key, value := largestField(data)
+		if message, _ := data["message"].(string); (!fieldsFirst && message != "") || value == "" {
+			key, value = "message", message
+		}
+		if value == "" {
+			return line, nil
+		}
+
+		data[key] = truncateString(value, len(value)-(len(line)-limit))
+	}
+}
+
+// splitLine spreads the message of an entry over lines that fit the maximum line size.
+// The first line keeps the fields, trimmed to half the line size if they are larger.
+// It falls back to shrinkLine when the message cannot be split.
+func (c *Client) splitLine(data map[string]any, id string) ([]string, error) {
+	message, _ := data["message"].(string)
+
+	// The number of parts is not known yet: use an upper bound so that the
+	// header of each part is never smaller than the final one
+	header := map[string]any{FieldSplitID: id, FieldSplitParts: len(message)}
+
+	first := maps.Clone(data)
+	maps.Copy(first, header)
+	first[FieldSplitPart] = 1
+	first["message"] = ""
+	if line, err := encodeLine(first); err != nil {
+		return nil, err
+	} else if len(line) > c.maxLineSize/2 {
+		if _, err := c.shrinkLine(first, c.maxLineSize/2, true); err != nil {
+			return nil, err
+		}
+	}
+
+	var parts []map[string]any
+	for part := first; message != ""; part = maps.Clone(header) {
+		part[FieldSplitPart] = len(parts) + 1
+
+		chunk, err := c.fitMessage(part, message)
+		if err != nil {
+			return nil, err
+		}
+		if chunk == "" {
+			break
+		}
+
+		message = message[len(chunk):]
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 || message != "" {
+		line, err := c.shrinkLine(data, c.maxLineSize, true)
+		if err != nil {
+			return nil, err
+		}
+		return []string{line}, nil
+	}
+
+	lines := make([]string, len(parts))
+	for i, part := range parts {
+		part[FieldSplitParts] = len(parts)
+
+		line, err := encodeLine(part)
+		if err != nil {
+			return nil, err
+		}
+		lines[i] = line
+	}
+
+	return lines, nil
+}
+
+// fitMessage sets the longest prefix of message that keeps the encoded part within
+// the maximum line size as the part's message, and returns it.
+func (c *Client) fitMessage(part map[string]any, message string) (string, error) {
+	chunk := truncateString(message, c.maxLineSize)
+
+	for {
+		part["message"] = chunk
+
+		line, err := encodeLine(part)
+		if err != nil {
+			return "", err
+		}
+		if len(line) <= c.maxLineSize || chunk == "" {
+			return chunk, nil
+		}
+
+		chunk = truncateString(chunk, len(chunk)-(len(line)-c.maxLineSize))
+	}
+}
+
+// largestField returns the field with the longest value, as a string, ignoring the
+// message and the markers added by the line size limit. Ties are broken by key.
+func largestField(data map[string]any) (string, string) {
+	var key, value string
+
+	for k, v := range data {
+		switch k {
+		case "message", FieldTruncated, FieldSplitID, FieldSplitPart, FieldSplitParts:
+			continue
+		}
+
+		s := stringValue(v)
+		if len(s) > len(value) || (len(s) == len(value) && len(s) > 0 && k < key) {
+			key, value = k, s
+		}
+	}
+
+	return key, value
+}
+
+// stringValue returns a string as is and any other value as JSON.
+func stringValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// truncateString cuts s to at most n bytes without splitting a UTF-8 character.
+func truncateString(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// splitID returns the ID shared by the lines of a split entry. It is derived from the
+// timestamp, labels and message of the entry, so that encoding the entry again, when a
+// request is retried or rejected entries are isolated, produces identical lines.
+func splitID(entry *types.Entry) string {
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, entry.Timestamp.UnixNano())
+	h.Write([]byte(entry.Labels.Key()))
+	h.Write([]byte{0})
+	h.Write([]byte(entry.Message))
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
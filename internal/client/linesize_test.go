@@ -0,0 +1,169 @@
+package client
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLines(t *testing.T, lines []string) []map[string]any {
+	t.Helper()
+
+	decoded := make([]map[string]any, len(lines))
+	for i, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded[i]))
+	}
+	return decoded
+}
+
+func TestClient_formatLogLines_UnderLimit(t *testing.T) {
+	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
+	c.SetLineLimit(1024, LineSplit)
+
+	lines, err := c.formatLogLines(&types.Entry{Message: "short", Fields: map[string]any{"user": "bob"}})
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+
+	data := decodeLines(t, lines)[0]
+	assert.Equal(t, "short", data["message"])
+	assert.Nil(t, data[FieldTruncated])
+	assert.Nil(t, data[FieldSplitID])
+}
+
+func TestClient_formatLogLines_Truncate(t *testing.T) {
+	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
+	c.SetLineLimit(200, LineTruncate)
+
+	entry := &types.Entry{
+		Message: strings.Repeat("é", 300),
+		Fields:  map[string]any{"user": "bob"},
+	}
+	lines, err := c.formatLogLines(entry)
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.LessOrEqual(t, len(lines[0]), 200)
+
+	data := decodeLines(t, lines)[0]
+	assert.Equal(t, true, data[FieldTruncated])
+	assert.Equal(t, "bob", data["user"])
+	assert.True(t, strings.HasPrefix(strings.Repeat("é", 300), data["message"].(string)))
+	assert.NotEmpty(t, data["message"])
+
+	// Fields are cut once the message is empty
+	entry = &types.Entry{Message: "boom", Fields: map[string]any{"stacktrace": strings.Repeat("x", 500)}}
+	lines, err = c.formatLogLines(entry)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(lines[0]), 200)
+	data = decodeLines(t, lines)[0]
+	assert.Equal(t, "", data["message"])
+	assert.NotEmpty(t, data["stacktrace"])
+}
+
+func TestClient_formatLogLines_TrimFields(t *testing.T) {
+	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
+	c.SetLineLimit(200, LineTrimFields)
+
+	entry := &types.Entry{
+		Message: "request failed",
+		Fields: map[string]any{
+			"user":       "bob",
+			"body":       strings.Repeat("b", 150),
+			"stacktrace": []any{strings.Repeat("frame ", 40), "main.go:12"},
+		},
+	}
+	lines, err := c.formatLogLines(entry)
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.LessOrEqual(t, len(lines[0]), 200)
+
+	data := decodeLines(t, lines)[0]
+	assert.Equal(t, true, data[FieldTruncated])
+	assert.Equal(t, "request failed", data["message"])
+	assert.Equal(t, "bob", data["user"])
+	assert.IsType(t, "", data["stacktrace"], "non-string values are trimmed as JSON")
+
+	// The entry itself is left untouched
+	assert.Len(t, entry.Fields["body"], 150)
+}
+
+func TestClient_formatLogLines_Split(t *testing.T) {
+	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
+	c.SetLineLimit(200, LineSplit)
+
+	message := strings.Repeat("0123456789", 60)
+	lines, err := c.formatLogLines(&types.Entry{Message: message, Fields: map[string]any{"user": "bob"}})
+	require.NoError(t, err)
+	require.Greater(t, len(lines), 3)
+
+	var rebuilt strings.Builder
+	parts := decodeLines(t, lines)
+	for i, part := range parts {
+		assert.LessOrEqual(t, len(lines[i]), 200)
+		assert.Equal(t, parts[0][FieldSplitID], part[FieldSplitID])
+		assert.Equal(t, float64(i+1), part[FieldSplitPart])
+		assert.Equal(t, float64(len(parts)), part[FieldSplitParts])
+		assert.Nil(t, part[FieldTruncated])
+		rebuilt.WriteString(part["message"].(string))
+	}
+	assert.Equal(t, message, rebuilt.String())
+
+	// Fields are only kept on the first line
+	assert.Equal(t, "bob", parts[0]["user"])
+	assert.Nil(t, parts[1]["user"])
+	assert.Len(t, parts[0][FieldSplitID], 16)
+
+	// Encoding the same entry again gives identical lines
+	again, err := c.formatLogLines(&types.Entry{Message: message, Fields: map[string]any{"user": "bob"}})
+	require.NoError(t, err)
+	assert.Equal(t, lines, again)
+	other, err := c.formatLogLines(&types.Entry{Message: message, Timestamp: time.Unix(0, 1)})
+	require.NoError(t, err)
+	assert.NotEqual(t, parts[0][FieldSplitID], decodeLines(t, other)[0][FieldSplitID])
+
+	// Nothing to split: fields are trimmed instead
+	lines, err = c.formatLogLines(&types.Entry{Message: "", Fields: map[string]any{"body": strings.Repeat("b", 500)}})
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.LessOrEqual(t, len(lines[0]), 200)
+	assert.Equal(t, true, decodeLines(t, lines)[0][FieldTruncated])
+}
+
+func TestClient_buildPayload_SplitTimestamps(t *testing.T) {
+	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
+	c.SetLineLimit(100, LineSplit)
+
+	ts := time.Unix(0, 1000)
+	payload, err := buildPayload(t, c, []*types.Entry{{
+		Message:   strings.Repeat("m", 300),
+		Labels:    types.Labels{"app": "test"},
+		Timestamp: ts,
+	}})
+	require.NoError(t, err)
+
+	var req struct {
+		Streams []struct {
+			Values [][]string `json:"values"`
+		} `json:"streams"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &req))
+	require.Len(t, req.Streams, 1)
+
+	values := req.Streams[0].Values
+	require.Greater(t, len(values), 1)
+	for i, v := range values {
+		assert.Equal(t, strconv.Itoa(1000+i), v[0])
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	assert.Equal(t, "abc", truncateString("abc", 5))
+	assert.Equal(t, "ab", truncateString("abc", 2))
+	assert.Equal(t, "", truncateString("abc", -1))
+	assert.Equal(t, "é", truncateString("éé", 3), "never splits a character")
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // PushRequest represents the JSON structure for Loki's push API.
 type PushRequest struct {
 	Streams []*Stream `json:"streams"`
@@ -9,5 +14,47 @@ type PushRequest struct {
 // Each stream groups log entries with the same label set.
 type Stream struct {
 	Stream map[string]string `json:"stream"` // Labels for this stream
-	Values [][]string        `json:"values"` // [[timestamp_ns, log_line], ...]
+	Values []Value           `json:"values"` // [[timestamp_ns, log_line(, structured_metadata)], ...]
+}
+
+// Value is a single log line of a stream.
+// It is encoded as [timestamp_ns, log_line], or as [timestamp_ns, log_line, structured_metadata]
+// when Metadata is not empty. Structured metadata requires Loki 3.x.
+type Value struct {
+	Timestamp string            // Unix epoch in nanoseconds
+	Line      string            // Log line
+	Metadata  map[string]string // Structured metadata (optional)
+}
+
+// MarshalJSON encodes the value as the array expected by Loki.
+func (v Value) MarshalJSON() ([]byte, error) {
+	if len(v.Metadata) == 0 {
+		return json.Marshal([]string{v.Timestamp, v.Line})
+	}
+	return json.Marshal([]any{v.Timestamp, v.Line, v.Metadata})
+}
+
+// UnmarshalJSON decodes a value from its array representation.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if len(raw) < 2 || len(raw) > 3 {
+		return fmt.Errorf("invalid stream value: expected 2 or 3 elements, got %d", len(raw))
+	}
+
+	if err := json.Unmarshal(raw[0], &v.Timestamp); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw[1], &v.Line); err != nil {
+		return err
+	}
+
+	v.Metadata = nil
+	if len(raw) == 3 {
+		return json.Unmarshal(raw[2], &v.Metadata)
+	}
+	return nil
 }
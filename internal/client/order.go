@@ -0,0 +1,84 @@
+package client
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/edaniel30/loki-logger-go/internal/client/models"
+)
+
+// timestampedValue is a stream value along with its timestamp in nanoseconds,
+// used to order the values of a stream before they are encoded.
+type timestampedValue struct {
+	ns    int64
+	value models.Value
+}
+
+// SetStrictTimestamps makes the timestamps of every stream strictly increasing, within a
+// push and across pushes: a timestamp equal to or older than the previous one of its stream
+// is moved forward to 1ns after it. Pushes are serialized so that they reach Loki in that
+// order. It must be called before the client is used.
+func (c *Client) SetStrictTimestamps(enabled bool) {
+	c.strictTimestamps = enabled
+	c.lastTimestamps = make(map[string]int64)
+}
+
+// orderValues sorts the values of a stream by timestamp, keeping the order of values with
+// equal timestamps, and returns the encoded values.
+func orderValues(values []timestampedValue) []models.Value {
+	slices.SortStableFunc(values, func(a, b timestampedValue) int {
+		return cmp.Compare(a.ns, b.ns)
+	})
+
+	ordered := make([]models.Value, len(values))
+	for i, v := range values {
+		ordered[i] = v.value
+		ordered[i].Timestamp = strconv.FormatInt(v.ns, 10)
+	}
+	return ordered
+}
+
+// enforceIncreasing sorts entries by timestamp and moves the timestamps of their values
+// forward so that they strictly increase within each stream, after the last timestamp
+// sent for the stream. It runs once per push, before entries are split into requests,
+// so that every request sending an entry uses the same timestamps.
+func (c *Client) enforceIncreasing(entries []*encodedEntry) {
+	slices.SortStableFunc(entries, func(a, b *encodedEntry) int {
+		return a.entry.Timestamp.Compare(b.entry.Timestamp)
+	})
+
+	c.mu.Lock()
+	last := make(map[string]int64, len(c.lastTimestamps))
+	maps.Copy(last, c.lastTimestamps)
+	c.mu.Unlock()
+
+	for _, e := range entries {
+		for i := range e.values {
+			if prev, seen := last[e.stream]; seen && e.values[i].ns <= prev {
+				e.values[i].ns = prev + 1
+			}
+			last[e.stream] = e.values[i].ns
+		}
+	}
+}
+
+// markSent records the timestamps of entries Loki accepted, with strict timestamps.
+// Entries that were not sent do not move the timestamps of later pushes forward.
+func (c *Client) markSent(entries []*encodedEntry) {
+	if !c.strictTimestamps {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, e := range entries {
+		for _, v := range e.values {
+			if prev, seen := c.lastTimestamps[e.stream]; !seen || v.ns > prev {
+				c.lastTimestamps[e.stream] = v.ns
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/internal/client/models"
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamTimestamps decodes a push payload and returns the timestamps of each stream, keyed by app label.
+func streamTimestamps(t *testing.T, payload []byte) map[string][]int64 {
+	t.Helper()
+
+	var req models.PushRequest
+	require.NoError(t, json.Unmarshal(payload, &req))
+
+	timestamps := make(map[string][]int64)
+	for _, s := range req.Streams {
+		for _, v := range s.Values {
+			ns, err := strconv.ParseInt(v.Timestamp, 10, 64)
+			require.NoError(t, err)
+			timestamps[s.Stream["app"]] = append(timestamps[s.Stream["app"]], ns)
+		}
+	}
+	return timestamps
+}
+
+func TestClient_buildPayloadSortsValues(t *testing.T) {
+	c := NewClient("http://localhost:3100", "", "", 10*time.Second, 3)
+
+	entry := func(app, message string, ns int64) *types.Entry {
+		return &types.Entry{Message: message, Timestamp: time.Unix(0, ns), Labels: types.Labels{"app": app}}
+	}
+	payload, err := buildPayload(t, c, []*types.Entry{
+		entry("a", "third", 30),
+		entry("b", "other", 5),
+		entry("a", "first", 10),
+		entry("a", "second-1", 20),
+		entry("a", "second-2", 20),
+	})
+	require.NoError(t, err)
+
+	var req models.PushRequest
+	require.NoError(t, json.Unmarshal(payload, &req))
+	for _, s := range req.Streams {
+		if s.Stream["app"] != "a" {
+			continue
+		}
+		var messages []string
+		for _, v := range s.Values {
+			var line map[string]any
+			require.NoError(t, json.Unmarshal([]byte(v.Line), &line))
+			messages = append(messages, line["message"].(string))
+		}
+		assert.Equal(t, []string{"first", "second-1", "second-2", "third"}, messages)
+	}
+
+	// Equal timestamps are kept without strict timestamps
+	assert.Equal(t, []int64{10, 20, 20, 30}, streamTimestamps(t, payload)["a"])
+}
+
+// recordingServer returns a server accepting pushes, unless fail reports true for the body,
+// and the payloads it accepted.
+func recordingServer(t *testing.T, fail func(body []byte) bool) (*httptest.Server, func() [][]byte) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var received [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if fail != nil && fail(body) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		mu.Lock()
+		received = append(received, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() [][]byte {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(received)
+	}
+}
+
+// pushedTimestamps returns the timestamps of the "a" stream in every payload, in order.
+func pushedTimestamps(t *testing.T, payloads [][]byte) []int64 {
+	t.Helper()
+
+	var all []int64
+	for _, body := range payloads {
+		all = append(all, streamTimestamps(t, body)["a"]...)
+	}
+	return all
+}
+
+func TestClient_StrictTimestamps(t *testing.T) {
+	server, received := recordingServer(t, func(body []byte) bool { return bytes.Contains(body, []byte("fail")) })
+	c := NewClient(server.URL, "", "", 10*time.Second, 0)
+	c.SetStrictTimestamps(true)
+	ctx := context.Background()
+
+	entry := func(app string, ns int64) *types.Entry {
+		return &types.Entry{Message: "m", Timestamp: time.Unix(0, ns), Labels: types.Labels{"app": app}}
+	}
+
+	payload, err := buildPayload(t, c, []*types.Entry{entry("a", 20), entry("a", 10), entry("a", 20), entry("a", 20), entry("b", 20)})
+	require.NoError(t, err)
+	timestamps := streamTimestamps(t, payload)
+	assert.Equal(t, []int64{10, 20, 21, 22}, timestamps["a"])
+	assert.Equal(t, []int64{20}, timestamps["b"], "streams are independent")
+
+	// Building a payload does not move later pushes forward, sending it does
+	require.NoError(t, c.Push(ctx, []*types.Entry{entry("a", 20), entry("a", 20)}))
+	assert.Equal(t, []int64{20, 21}, pushedTimestamps(t, received()))
+
+	// Entries older than the last one sent are moved after it
+	require.NoError(t, c.Push(ctx, []*types.Entry{entry("a", 15), entry("a", 100)}))
+	assert.Equal(t, []int64{20, 21, 22, 100}, pushedTimestamps(t, received()))
+
+	// A failed push does not move later pushes forward
+	failed := entry("a", 500)
+	failed.Message = "fail"
+	require.Error(t, c.Push(ctx, []*types.Entry{failed}))
+	require.NoError(t, c.Push(ctx, []*types.Entry{entry("a", 200)}))
+	assert.Equal(t, []int64{20, 21, 22, 100, 200}, pushedTimestamps(t, received()))
+}
+
+func TestClient_StrictTimestampsSplitPush(t *testing.T) {
+	server, received := recordingServer(t, nil)
+	c := NewClient(server.URL, "", "", 10*time.Second, 0)
+	c.SetStrictTimestamps(true)
+	c.SetMaxBatchBytes(200)
+
+	entries := make([]*types.Entry, 0, 9)
+	for i := range 6 {
+		entries = append(entries, &types.Entry{Message: "m", Timestamp: time.Unix(0, int64(6000-1000*i)), Labels: types.Labels{"app": "a"}})
+	}
+	for range 3 {
+		entries = append(entries, &types.Entry{Message: "m", Timestamp: time.Unix(0, 7000), Labels: types.Labels{"app": "a"}})
+	}
+
+	require.NoError(t, c.Push(context.Background(), entries))
+	require.Greater(t, len(received()), 2, "the push is split")
+
+	// Real timestamps are kept across requests, and collisions only move by 1ns
+	assert.Equal(t, []int64{1000, 2000, 3000, 4000, 5000, 6000, 7000, 7001, 7002}, pushedTimestamps(t, received()))
+}
+
+func TestClient_StrictTimestampsConcurrentPushes(t *testing.T) {
+	server, received := recordingServer(t, nil)
+
+	c := NewClient(server.URL, "", "", 10*time.Second, 0)
+	c.SetStrictTimestamps(true)
+
+	// Every goroutine pushes batches whose timestamps collide with the other goroutines'
+	base := time.Now()
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for b := range 10 {
+				entries := make([]*types.Entry, 5)
+				for i := range entries {
+					entries[i] = &types.Entry{
+						Message:   "m",
+						Timestamp: base.Add(time.Duration((b*5+i+g)%7) * time.Nanosecond),
+						Labels:    types.Labels{"app": "a"},
+					}
+				}
+				assert.NoError(t, c.Push(context.Background(), entries))
+			}
+		})
+	}
+	wg.Wait()
+
+	// Requests reach Loki in order: timestamps strictly increase across all of them
+	all := pushedTimestamps(t, received())
+	require.Len(t, all, 8*10*5)
+	for i := 1; i < len(all); i++ {
+		require.Greater(t, all[i], all[i-1], "timestamp %d is not after the previous one", i)
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// isRejection reports whether Loki refused the content of a request, for example
+// because of an out-of-order entry, a line that is too long or an invalid label.
+func isRejection(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest
+}
+
+// maxIsolationDepth bounds how many times a rejected request is split while isolating
+// rejected entries, so that a request rejected as a whole costs at most 30 more requests
+// for a single stream. Groups still rejected at that depth are rejected as a whole.
+const maxIsolationDepth = 4
+
+// isolateRejected pushes entries from a rejected request again in smaller requests,
+// one per stream and then in halves, until the entries Loki rejects are isolated or
+// maxIsolationDepth is reached. depth is the number of splits already made.
+// Rejected entries are reported to onRejected and dropped. Sending accepted entries
+// again is safe: the requests reuse the lines and timestamps encoded for the first one,
+// and Loki ignores lines identical to one already stored with the same timestamp.
+func (c *Client) isolateRejected(ctx context.Context, entries []*encodedEntry, err error, depth int) error {
+	if len(entries) == 1 || depth >= maxIsolationDepth {
+		rejected := sourceEntries(entries)
+		if c.metrics != nil {
+			c.metrics.Rejected.Add(uint64(len(rejected)))
+		}
+		if c.onRejected != nil {
+			c.onRejected(rejected, err)
+		}
+		return nil
+	}
+
+	groups := groupByStream(entries)
+	if len(groups) == 1 {
+		mid := len(entries) / 2
+		groups = [][]*encodedEntry{entries[:mid], entries[mid:]}
+	}
+
+	var firstErr error
+	for _, group := range groups {
+		// Stop isolating once the circuit opened, the server is the problem
+		if c.breaker != nil && c.breaker.State() != BreakerClosed {
+			if firstErr == nil {
+				firstErr = ErrCircuitOpen
+			}
+			break
+		}
+
+		payload, err := c.encodePayload(group)
+		if err == nil {
+			err = c.pushBatch(ctx, batch{entries: group, payload: payload})
+		}
+
+		if err != nil && isRejection(err) && ctx.Err() == nil {
+			err = c.isolateRejected(ctx, group, err, depth+1)
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// groupByStream splits entries per label set, keeping their order within each stream.
+func groupByStream(entries []*encodedEntry) [][]*encodedEntry {
+	index := make(map[string]int)
+	var groups [][]*encodedEntry
+
+	for _, entry := range entries {
+		key := entry.stream
+		i, exists := index[key]
+		if !exists {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], entry)
+	}
+
+	return groups
+}
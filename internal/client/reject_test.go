@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/internal/client/models"
+	"github.com/edaniel30/loki-logger-go/internal/metrics"
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_PushIsolatesRejectedEntries(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	accepted := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+
+		if bytes.Contains(body, []byte("malformed")) {
+			http.Error(w, "entry for stream '{app=\"api\"}' has timestamp too old", http.StatusBadRequest)
+			return
+		}
+
+		var req models.PushRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		for _, s := range req.Streams {
+			for _, v := range s.Values {
+				var line map[string]any
+				require.NoError(t, json.Unmarshal([]byte(v.Line), &line))
+				accepted[line["message"].(string)] = true
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var rejected []*types.Entry
+	var rejectErr error
+	m := &metrics.Transport{PushLatency: metrics.NewHistogram(metrics.DefaultBuckets)}
+	c := NewClient(server.URL, "", "", 10*time.Second, 3)
+	c.SetMetrics(m)
+	c.SetOnRejected(func(entries []*types.Entry, err error) {
+		rejected = append(rejected, entries...)
+		rejectErr = err
+	})
+
+	entries := make([]*types.Entry, 0, 8)
+	for i, message := range []string{"a", "b", "malformed", "c", "d", "e", "f", "g"} {
+		app := "api"
+		if i%2 == 0 {
+			app = "worker"
+		}
+		entries = append(entries, &types.Entry{Message: message, Timestamp: time.Now(), Labels: types.Labels{"app": app}})
+	}
+
+	require.NoError(t, c.Push(context.Background(), entries))
+
+	require.Len(t, rejected, 1)
+	assert.Equal(t, "malformed", rejected[0].Message)
+	var statusErr *StatusError
+	require.ErrorAs(t, rejectErr, &statusErr)
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+
+	assert.Len(t, accepted, 7)
+	assert.NotContains(t, accepted, "malformed")
+	assert.Equal(t, uint64(7), m.Pushed.Load())
+	assert.Equal(t, uint64(1), m.Rejected.Load())
+	assert.Zero(t, m.PushErrors.Load())
+	assert.Zero(t, m.Retries.Load(), "rejected requests are not retried")
+	assert.Less(t, requests, 10)
+}
+
+func TestClient_PushRejectedWithoutCallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "", "", 10*time.Second, 3)
+	entries := []*types.Entry{
+		{Message: "one", Timestamp: time.Now(), Labels: types.Labels{"app": "test"}},
+		{Message: "two", Timestamp: time.Now(), Labels: types.Labels{"app": "test"}},
+	}
+
+	// Every entry is rejected and dropped
+	assert.NoError(t, c.Push(context.Background(), entries))
+}
+
+func TestClient_PushRejectedResendsIdenticalLines(t *testing.T) {
+	var mu sync.Mutex
+	sent := make(map[string]string) // timestamp of every line sent
+	splitIDs := make(map[any]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req models.PushRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, s := range req.Streams {
+			for _, v := range s.Values {
+				if ts, exists := sent[v.Line]; exists {
+					assert.Equal(t, ts, v.Timestamp, "line sent again with another timestamp")
+				}
+				sent[v.Line] = v.Timestamp
+
+				var line map[string]any
+				require.NoError(t, json.Unmarshal([]byte(v.Line), &line))
+				if id, ok := line[FieldSplitID]; ok {
+					splitIDs[id] = true
+				}
+			}
+		}
+
+		if bytes.Contains(body, []byte("malformed")) {
+			http.Error(w, "invalid", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "", "", 10*time.Second, 0)
+	c.SetLineLimit(120, LineSplit)
+
+	base := time.Now()
+	entries := []*types.Entry{
+		{Message: strings.Repeat("long ", 60), Timestamp: base, Labels: types.Labels{"app": "api"}},
+		{Message: "malformed", Timestamp: base.Add(time.Millisecond), Labels: types.Labels{"app": "api"}},
+		{Message: "other", Timestamp: base.Add(2 * time.Millisecond), Labels: types.Labels{"app": "api"}},
+	}
+	require.NoError(t, c.Push(context.Background(), entries))
+	assert.Len(t, splitIDs, 1, "the split entry keeps its split_id when sent again")
+}
+
+func TestClient_PushRejectedBoundsRequests(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "invalid", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var rejected []*types.Entry
+	c := NewClient(server.URL, "", "", 10*time.Second, 3)
+	c.SetOnRejected(func(entries []*types.Entry, err error) {
+		rejected = append(rejected, entries...)
+	})
+
+	entries := make([]*types.Entry, 100)
+	for i := range entries {
+		entries[i] = &types.Entry{Message: "m" + strconv.Itoa(i), Timestamp: time.Now(), Labels: types.Labels{"app": "test"}}
+	}
+
+	require.NoError(t, c.Push(context.Background(), entries))
+	assert.Len(t, rejected, 100, "every entry is reported once")
+	assert.LessOrEqual(t, requests, 1+30)
+}
+
+func TestGroupByStream(t *testing.T) {
+	encode := func(message, app string) *encodedEntry {
+		labels := types.Labels{"app": app}
+		return &encodedEntry{entry: &types.Entry{Message: message, Labels: labels}, stream: labels.Key()}
+	}
+	a1, b1, a2 := encode("a1", "a"), encode("b1", "b"), encode("a2", "a")
+
+	assert.Equal(t, [][]*encodedEntry{{a1, a2}, {b1}}, groupByStream([]*encodedEntry{a1, b1, a2}))
+}
+
+func TestIsRejection(t *testing.T) {
+	assert.True(t, isRejection(&StatusError{StatusCode: http.StatusBadRequest}))
+	assert.False(t, isRejection(&StatusError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, isRejection(&StatusError{StatusCode: http.StatusInternalServerError}))
+	assert.False(t, isRejection(context.DeadlineExceeded))
+}
@@ -0,0 +1,178 @@
+package dedupe
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+const (
+	// FieldRepeatCount is the field holding how many duplicates were suppressed.
+	FieldRepeatCount = "repeat_count"
+	// FieldFirstTimestamp is the field holding the timestamp of the first occurrence.
+	FieldFirstTimestamp = "first_timestamp"
+	// FieldLastTimestamp is the field holding the timestamp of the last suppressed duplicate.
+	FieldLastTimestamp = "last_timestamp"
+)
+
+// sweepsPerWindow is how many times per window the sweeper looks for expired windows,
+// bounding how late a summary is emitted to a fraction of the window.
+const sweepsPerWindow = 10
+
+// Deduper collapses identical entries (same level, message and labels) seen within a window.
+// The first occurrence passes through immediately; later duplicates are counted and
+// reported as a single summary entry when the window closes (within a tenth of the
+// window) or on Flush.
+// Deduper is safe for concurrent use.
+type Deduper struct {
+	window time.Duration
+	emit   func(*types.Entry)
+	groups map[string]*group
+	now    func() time.Time
+	mu     sync.Mutex
+	stopCh chan struct{}
+	doneCh chan struct{} // signals when background sweeper is done
+	once   sync.Once
+}
+
+// group tracks the duplicates of a single entry within the current window.
+type group struct {
+	first   *types.Entry
+	count   int
+	last    time.Time
+	expires time.Time
+}
+
+// New creates a deduper with the given window and starts its background sweeper.
+// Summary entries are passed to emit, which must not call back into the deduper.
+func New(window time.Duration, emit func(*types.Entry)) *Deduper {
+	d := &Deduper{
+		window: window,
+		emit:   emit,
+		groups: make(map[string]*group),
+		now:    time.Now,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	go d.sweeper()
+
+	return d
+}
+
+// Add records an entry and reports whether it should be written now.
+// It returns false for duplicates that are being collapsed.
+func (d *Deduper) Add(entry *types.Entry) bool {
+	key := entryKey(entry)
+
+	d.mu.Lock()
+	var expired *types.Entry
+	g, exists := d.groups[key]
+	if exists && !d.now().Before(g.expires) {
+		// Window closed but the sweeper has not run yet: close it here
+		expired = g.summary()
+		exists = false
+	}
+	if exists {
+		g.count++
+		g.last = entry.Timestamp
+		d.mu.Unlock()
+		return false
+	}
+	d.groups[key] = &group{first: entry, expires: d.now().Add(d.window)}
+	d.mu.Unlock()
+
+	if expired != nil {
+		d.emit(expired)
+	}
+
+	return true
+}
+
+// Flush closes all open windows, emitting a summary for every group with duplicates.
+func (d *Deduper) Flush() {
+	d.emitAll(d.take(func(*group) bool { return true }))
+}
+
+// Close stops the background sweeper and flushes pending summaries.
+// It is safe to call Close more than once.
+func (d *Deduper) Close() {
+	d.once.Do(func() { close(d.stopCh) })
+	<-d.doneCh
+}
+
+// sweeper periodically closes expired windows. It ticks several times per window:
+// ticking once per window would let a summary arrive up to twice the window late.
+func (d *Deduper) sweeper() {
+	defer close(d.doneCh)
+
+	ticker := time.NewTicker(max(d.window/sweepsPerWindow, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := d.now()
+			d.emitAll(d.take(func(g *group) bool { return !now.Before(g.expires) }))
+
+		case <-d.stopCh:
+			d.Flush()
+			return
+		}
+	}
+}
+
+// take removes the groups matching the predicate and returns their summaries.
+func (d *Deduper) take(match func(*group) bool) []*types.Entry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var summaries []*types.Entry
+	for key, g := range d.groups {
+		if !match(g) {
+			continue
+		}
+		delete(d.groups, key)
+		if s := g.summary(); s != nil {
+			summaries = append(summaries, s)
+		}
+	}
+
+	return summaries
+}
+
+// emitAll passes summaries to the emit callback outside of the lock.
+func (d *Deduper) emitAll(summaries []*types.Entry) {
+	for _, s := range summaries {
+		d.emit(s)
+	}
+}
+
+// summary builds the entry reporting suppressed duplicates, or nil if there were none.
+func (g *group) summary() *types.Entry {
+	if g.count == 0 {
+		return nil
+	}
+
+	fields := make(map[string]any, len(g.first.Fields)+3)
+	maps.Copy(fields, g.first.Fields)
+	fields[FieldRepeatCount] = g.count
+	fields[FieldFirstTimestamp] = g.first.Timestamp.Format(time.RFC3339Nano)
+	fields[FieldLastTimestamp] = g.last.Format(time.RFC3339Nano)
+
+	return &types.Entry{
+		Level:     g.first.Level,
+		Message:   g.first.Message,
+		Fields:    fields,
+		Timestamp: g.last,
+		Labels:    g.first.Labels,
+		Metadata:  g.first.Metadata,
+	}
+}
+
+// entryKey identifies duplicates by level, message and labels.
+func entryKey(entry *types.Entry) string {
+	return entry.Level.String() + "\x00" + entry.Message + "\x00" + entry.Labels.Key()
+}
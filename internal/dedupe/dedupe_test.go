@@ -0,0 +1,127 @@
+package dedupe
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sink struct {
+	mu      sync.Mutex
+	entries []*types.Entry
+}
+
+func (s *sink) emit(entry *types.Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+}
+
+func (s *sink) get() []*types.Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*types.Entry(nil), s.entries...)
+}
+
+func newEntry(message string, ts time.Time) *types.Entry {
+	return &types.Entry{
+		Level:     types.LevelError,
+		Message:   message,
+		Fields:    map[string]any{"dependency": "db"},
+		Timestamp: ts,
+		Labels:    types.Labels{"app": "test"},
+	}
+}
+
+func TestDeduper_Flush(t *testing.T) {
+	s := &sink{}
+	d := New(time.Hour, s.emit)
+	defer d.Close()
+
+	start := time.Unix(1000, 0)
+	assert.True(t, d.Add(newEntry("db down", start)))
+	assert.False(t, d.Add(newEntry("db down", start.Add(time.Second))))
+	assert.False(t, d.Add(newEntry("db down", start.Add(2*time.Second))))
+	assert.True(t, d.Add(newEntry("other", start)))
+
+	// Different labels are a different group
+	other := newEntry("db down", start)
+	other.Labels = types.Labels{"app": "other"}
+	assert.True(t, d.Add(other))
+
+	assert.Empty(t, s.get())
+	d.Flush()
+
+	entries := s.get()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "db down", entries[0].Message)
+	assert.Equal(t, 2, entries[0].Fields[FieldRepeatCount])
+	assert.Equal(t, "db", entries[0].Fields["dependency"])
+	assert.Equal(t, start.Format(time.RFC3339Nano), entries[0].Fields[FieldFirstTimestamp])
+	assert.Equal(t, start.Add(2*time.Second).Format(time.RFC3339Nano), entries[0].Fields[FieldLastTimestamp])
+	assert.Equal(t, start.Add(2*time.Second), entries[0].Timestamp)
+
+	// Window starts again after flush
+	assert.True(t, d.Add(newEntry("db down", start)))
+}
+
+func TestDeduper_ExpiredOnAdd(t *testing.T) {
+	s := &sink{}
+	d := New(time.Hour, s.emit)
+	defer d.Close()
+
+	now := time.Unix(1000, 0)
+	d.now = func() time.Time { return now }
+
+	assert.True(t, d.Add(newEntry("db down", now)))
+	assert.False(t, d.Add(newEntry("db down", now)))
+
+	now = now.Add(2 * time.Hour)
+	assert.True(t, d.Add(newEntry("db down", now)))
+
+	entries := s.get()
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Fields[FieldRepeatCount])
+}
+
+func TestDeduper_Sweeper(t *testing.T) {
+	s := &sink{}
+	d := New(20*time.Millisecond, s.emit)
+	defer d.Close()
+
+	assert.True(t, d.Add(newEntry("db down", time.Now())))
+	assert.False(t, d.Add(newEntry("db down", time.Now())))
+
+	assert.Eventually(t, func() bool { return len(s.get()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestDeduper_SweeperOnTime(t *testing.T) {
+	const window = 200 * time.Millisecond
+	s := &sink{}
+	d := New(window, s.emit)
+	defer d.Close()
+
+	// Start the window just after a sweep, the worst case for a once-per-window ticker
+	time.Sleep(10 * time.Millisecond)
+	start := time.Now()
+	assert.True(t, d.Add(newEntry("db down", start)))
+	assert.False(t, d.Add(newEntry("db down", start)))
+
+	require.Eventually(t, func() bool { return len(s.get()) == 1 }, 2*window, time.Millisecond)
+	assert.Less(t, time.Since(start), window+window/2, "summary emitted long after the window closed")
+}
+
+func TestDeduper_Close(t *testing.T) {
+	s := &sink{}
+	d := New(time.Hour, s.emit)
+
+	d.Add(newEntry("db down", time.Now()))
+	d.Add(newEntry("db down", time.Now()))
+	d.Close()
+
+	assert.Len(t, s.get(), 1)
+}
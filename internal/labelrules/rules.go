@@ -0,0 +1,228 @@
+package labelrules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// Mode determines how Apply handles labels that break the rules.
+type Mode int
+
+const (
+	// Sanitize rewrites invalid names, truncates long names and values and
+	// drops labels beyond the maximum count.
+	Sanitize Mode = iota
+	// Drop removes every label that breaks a rule.
+	Drop
+)
+
+// reservedPrefix marks label names reserved for Loki and Prometheus internals.
+const reservedPrefix = "__"
+
+// Rules enforces Loki's label constraints. A zero limit disables that limit.
+type Rules struct {
+	Mode           Mode
+	MaxLabels      int // maximum number of labels per entry
+	MaxNameLength  int // maximum label name length in bytes
+	MaxValueLength int // maximum label value length in bytes
+}
+
+// ValidName reports whether name matches [a-zA-Z_][a-zA-Z0-9_]*.
+func ValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// SanitizeName rewrites name to match [a-zA-Z_][a-zA-Z0-9_]* without the reserved "__" prefix.
+// Invalid characters become underscores and a leading digit is prefixed with one,
+// so "http-status" becomes "http_status" and "k8s.pod" becomes "k8s_pod".
+func SanitizeName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name) + 1)
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_':
+			b.WriteByte(c)
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	sanitized := b.String()
+	if strings.HasPrefix(sanitized, reservedPrefix) {
+		sanitized = "_" + strings.TrimLeft(sanitized, "_")
+	}
+	if sanitized == "" || sanitized == "_" {
+		return ""
+	}
+	return sanitized
+}
+
+// Error describes a broken label rule.
+type Error struct {
+	Label  string // offending label name, empty when the label count is exceeded
+	Reason string
+}
+
+func (e *Error) Error() string {
+	if e.Label == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("label %q: %s", e.Label, e.Reason)
+}
+
+// Check returns an *Error describing the first rule broken by a single label.
+func (r Rules) Check(name, value string) error {
+	switch {
+	case !ValidName(name):
+		return &Error{Label: name, Reason: "name must match [a-zA-Z_][a-zA-Z0-9_]*"}
+	case strings.HasPrefix(name, reservedPrefix):
+		return &Error{Label: name, Reason: fmt.Sprintf("names starting with %q are reserved", reservedPrefix)}
+	case r.MaxNameLength > 0 && len(name) > r.MaxNameLength:
+		return &Error{Label: name, Reason: fmt.Sprintf("name is longer than %d bytes", r.MaxNameLength)}
+	case r.MaxValueLength > 0 && len(value) > r.MaxValueLength:
+		return &Error{Label: name, Reason: fmt.Sprintf("value is longer than %d bytes", r.MaxValueLength)}
+	}
+	return nil
+}
+
+// CheckAll returns an *Error describing the first broken rule in labels, checking names in sorted order.
+// Labels listed in reserved are added by the logger on its own and count towards MaxLabels.
+func (r Rules) CheckAll(labels types.Labels, reserved ...string) error {
+	for _, name := range sortedNames(labels) {
+		if err := r.Check(name, labels[name]); err != nil {
+			return err
+		}
+	}
+
+	count := len(labels)
+	for _, name := range reserved {
+		if _, exists := labels[name]; !exists {
+			count++
+		}
+	}
+	if r.MaxLabels > 0 && count > r.MaxLabels {
+		return &Error{Reason: fmt.Sprintf("%d labels exceed the maximum of %d", count, r.MaxLabels)}
+	}
+	return nil
+}
+
+// Apply enforces the rules on labels in place and returns the names of the labels it
+// removed. Labels listed in keep are never removed or renamed and are counted first
+// towards MaxLabels; the rest are kept in alphabetical order until the limit is reached.
+func (r Rules) Apply(labels types.Labels, keep ...string) []string {
+	if r.valid(labels) {
+		return nil
+	}
+
+	var removed []string
+
+	protected := make(map[string]bool, len(keep))
+	for _, name := range keep {
+		if _, exists := labels[name]; exists {
+			protected[name] = true
+		}
+	}
+
+	for _, name := range sortedNames(labels) {
+		value := labels[name]
+		if protected[name] {
+			labels[name] = r.truncateValue(value)
+			continue
+		}
+		if r.Check(name, value) == nil {
+			continue
+		}
+
+		delete(labels, name)
+		if r.Mode == Drop {
+			removed = append(removed, name)
+			continue
+		}
+
+		sanitized := r.truncateName(SanitizeName(name))
+		if _, taken := labels[sanitized]; sanitized == "" || taken {
+			removed = append(removed, name)
+			continue
+		}
+		labels[sanitized] = r.truncateValue(value)
+	}
+
+	if r.MaxLabels > 0 && len(labels) > r.MaxLabels {
+		budget := r.MaxLabels - len(protected)
+		for _, name := range sortedNames(labels) {
+			if protected[name] {
+				continue
+			}
+			if budget > 0 {
+				budget--
+				continue
+			}
+			delete(labels, name)
+			removed = append(removed, name)
+		}
+	}
+
+	return removed
+}
+
+// valid reports whether labels already satisfy every rule, without allocating.
+func (r Rules) valid(labels types.Labels) bool {
+	if r.MaxLabels > 0 && len(labels) > r.MaxLabels {
+		return false
+	}
+	for name, value := range labels {
+		if r.Check(name, value) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Rules) truncateName(name string) string {
+	if r.MaxNameLength > 0 && len(name) > r.MaxNameLength {
+		return name[:r.MaxNameLength]
+	}
+	return name
+}
+
+// truncateValue shortens value to MaxValueLength bytes without splitting a UTF-8 character.
+func (r Rules) truncateValue(value string) string {
+	if r.MaxValueLength <= 0 || len(value) <= r.MaxValueLength {
+		return value
+	}
+	cut := r.MaxValueLength
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut]
+}
+
+func sortedNames(labels types.Labels) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
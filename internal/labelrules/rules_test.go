@@ -0,0 +1,106 @@
+package labelrules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidName(t *testing.T) {
+	for _, name := range []string{"app", "_private", "http_status", "K8s_pod2", "__name__"} {
+		assert.True(t, ValidName(name), name)
+	}
+	for _, name := range []string{"", "http-status", "k8s.pod", "2xx", "naïve", "a b"} {
+		assert.False(t, ValidName(name), name)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"http-status": "http_status",
+		"k8s.pod":     "k8s_pod",
+		"2xx":         "_2xx",
+		"__tenant":    "_tenant",
+		"___":         "",
+		"-":           "",
+		"naïve":       "na__ve",
+		"valid_name":  "valid_name",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, SanitizeName(in), in)
+	}
+}
+
+func TestRules_Check(t *testing.T) {
+	r := Rules{MaxNameLength: 8, MaxValueLength: 4}
+
+	assert.NoError(t, r.Check("app", "api"))
+	assert.EqualError(t, r.Check("k8s.pod", "x"), `label "k8s.pod": name must match [a-zA-Z_][a-zA-Z0-9_]*`)
+	assert.EqualError(t, r.Check("__name__", "x"), `label "__name__": names starting with "__" are reserved`)
+	assert.EqualError(t, r.Check("very_long_name", "x"), `label "very_long_name": name is longer than 8 bytes`)
+	assert.EqualError(t, r.Check("app", "too long"), `label "app": value is longer than 4 bytes`)
+
+	var ruleErr *Error
+	require.ErrorAs(t, r.Check("k8s.pod", "x"), &ruleErr)
+	assert.Equal(t, "k8s.pod", ruleErr.Label)
+}
+
+func TestRules_CheckAll(t *testing.T) {
+	r := Rules{MaxLabels: 4}
+
+	assert.NoError(t, r.CheckAll(types.Labels{"a": "1", "b": "2"}, "app", "level"))
+	assert.NoError(t, r.CheckAll(types.Labels{"a": "1", "app": "x", "level": "y"}, "app", "level"))
+	assert.EqualError(t, r.CheckAll(types.Labels{"a": "1", "b": "2", "c": "3"}, "app", "level"), "5 labels exceed the maximum of 4")
+	assert.Error(t, r.CheckAll(types.Labels{"ok": "1", "not-ok": "2"}))
+}
+
+func TestRules_ApplySanitize(t *testing.T) {
+	r := Rules{Mode: Sanitize, MaxLabels: 5, MaxNameLength: 12, MaxValueLength: 6}
+
+	labels := types.Labels{
+		"app":               strings.Repeat("a", 10),
+		"http-status":       "200",
+		"http_status":       "201", // wins over the sanitized duplicate
+		"k8s.pod":           "pod-1",
+		"__tenant":          "acme",
+		"region":            "eu-west-1",
+		"a_very_long_label": "x",
+		"zone":              "b",
+	}
+
+	removed := r.Apply(labels, "app")
+
+	assert.Equal(t, types.Labels{
+		"app":          "aaaaaa", // protected labels are kept but truncated
+		"_tenant":      "acme",
+		"a_very_long_": "x",
+		"http_status":  "201",
+		"k8s_pod":      "pod-1",
+	}, labels)
+	assert.ElementsMatch(t, []string{"http-status", "region", "zone"}, removed)
+}
+
+func TestRules_ApplyDrop(t *testing.T) {
+	r := Rules{Mode: Drop, MaxValueLength: 4}
+
+	labels := types.Labels{"app": "api", "k8s.pod": "p", "__tenant": "x", "region": "eu-west-1", "zone": "b"}
+	removed := r.Apply(labels, "app")
+
+	assert.Equal(t, types.Labels{"app": "api", "zone": "b"}, labels)
+	assert.Equal(t, []string{"__tenant", "k8s.pod", "region"}, removed)
+}
+
+func TestRules_ApplyValid(t *testing.T) {
+	r := Rules{MaxLabels: 2}
+	labels := types.Labels{"app": "api", "level": "info"}
+	assert.Nil(t, r.Apply(labels))
+	assert.Len(t, labels, 2)
+}
+
+func TestRules_TruncateValueUTF8(t *testing.T) {
+	r := Rules{MaxValueLength: 3}
+	assert.Equal(t, "ab", r.truncateValue("abéé")) // does not split the 2-byte "é"
+}
@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the push latency histogram.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// numLevels is the number of log levels tracked per level; LevelPanic has the highest value.
+const numLevels = int(types.LevelPanic) + 1
+
+// Counter is a monotonically increasing counter safe for concurrent use.
+type Counter struct {
+	v atomic.Uint64
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.v.Add(1)
+}
+
+// Add increments the counter by n.
+func (c *Counter) Add(n uint64) {
+	c.v.Add(n)
+}
+
+// Load returns the current value.
+func (c *Counter) Load() uint64 {
+	return c.v.Load()
+}
+
+// Histogram counts observations into fixed buckets. It is safe for concurrent use.
+type Histogram struct {
+	bounds  []float64
+	counts  []atomic.Uint64 // one per bound plus the +Inf bucket
+	sumBits atomic.Uint64   // float64 bits of the sum of observations
+	count   atomic.Uint64
+}
+
+// NewHistogram creates a histogram with the given ascending upper bounds.
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+}
+
+// Observe records a single value.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v)
+	h.counts[i].Add(1)
+	h.count.Add(1)
+
+	for {
+		old := h.sumBits.Load()
+		sum := math.Float64bits(math.Float64frombits(old) + v)
+		if h.sumBits.CompareAndSwap(old, sum) {
+			return
+		}
+	}
+}
+
+// Snapshot returns the cumulative bucket counts, the sum and the count of observations.
+// Cumulative counts are returned per bound, excluding the +Inf bucket which equals count.
+func (h *Histogram) Snapshot() (bounds []float64, cumulative []uint64, sum float64, count uint64) {
+	cumulative = make([]uint64, len(h.bounds))
+	var total uint64
+	for i := range h.bounds {
+		total += h.counts[i].Load()
+		cumulative[i] = total
+	}
+	count = total + h.counts[len(h.bounds)].Load()
+	return h.bounds, cumulative, math.Float64frombits(h.sumBits.Load()), count
+}
+
+// Transport holds the counters of a single transport.
+// Batch, push and retry counters are only updated by batching transports such as Loki.
+type Transport struct {
+	Written     Counter // entries handed to Write
+	WriteErrors Counter // Write calls that returned an error
+	Batches     Counter // push requests attempted, one per flushed batch
+	Pushed      Counter // entries successfully pushed
+	Retries     Counter // retried push attempts
+	PushErrors  Counter // batches that failed after all retries
+	Failed      Counter // entries lost because their batch failed or did not fit the buffer
+	Rejected    Counter // entries Loki refused with a 400, isolated from their batch
+	PushLatency *Histogram
+
+	lastSuccess         atomic.Int64 // unix nanoseconds of the last successful push
+	consecutiveFailures atomic.Int64
+	lastError           atomic.Pointer[string]
+}
+
+// PushSucceeded records a successful push and resets the failure streak.
+func (t *Transport) PushSucceeded() {
+	t.lastSuccess.Store(time.Now().UnixNano())
+	t.consecutiveFailures.Store(0)
+}
+
+// PushFailed records a failed push.
+func (t *Transport) PushFailed(err error) {
+	t.consecutiveFailures.Add(1)
+	if err != nil {
+		msg := err.Error()
+		t.lastError.Store(&msg)
+	}
+}
+
+// LastSuccess returns the time of the last successful push, or the zero time if none.
+func (t *Transport) LastSuccess() time.Time {
+	ns := t.lastSuccess.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// ConsecutiveFailures returns the number of failed pushes since the last success.
+func (t *Transport) ConsecutiveFailures() int {
+	return int(t.consecutiveFailures.Load())
+}
+
+// LastError returns the message of the last push error, or "" if none.
+func (t *Transport) LastError() string {
+	if msg := t.lastError.Load(); msg != nil {
+		return *msg
+	}
+	return ""
+}
+
+// ObservePush records the latency of a single push attempt.
+func (t *Transport) ObservePush(d time.Duration) {
+	t.PushLatency.Observe(d.Seconds())
+}
+
+// Registry holds the counters of the logging pipeline.
+// A nil *Registry is not valid; use New.
+type Registry struct {
+	logged         [numLevels]Counter
+	HookDropped    Counter // entries dropped by a hook
+	RateLimited    Counter // entries dropped or downgraded by the rate limiter
+	Deduplicated   Counter // entries collapsed into a deduplication summary
+	LabelOverflows Counter // promoted label values replaced because of the cardinality limit
+	OutOfWindow    Counter // entries dropped because their timestamp is outside Loki's accepted window
+
+	transports map[string]*Transport
+	mu         sync.Mutex
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{
+		transports: make(map[string]*Transport),
+	}
+}
+
+// Logged increments the counter of entries accepted at the given level.
+func (r *Registry) Logged(level types.Level) {
+	if level >= 0 && int(level) < numLevels {
+		r.logged[level].Inc()
+	}
+}
+
+// LoggedCount returns the number of entries accepted at the given level.
+func (r *Registry) LoggedCount(level types.Level) uint64 {
+	if level < 0 || int(level) >= numLevels {
+		return 0
+	}
+	return r.logged[level].Load()
+}
+
+// Transport returns the counters for the named transport, creating them on first use.
+func (r *Registry) Transport(name string) *Transport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, exists := r.transports[name]
+	if !exists {
+		t = &Transport{PushLatency: NewHistogram(DefaultBuckets)}
+		r.transports[name] = t
+	}
+	return t
+}
+
+// TransportNames returns the names of all known transports, sorted.
+func (r *Registry) TransportNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.transports))
+	for name := range r.transports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 1})
+
+	h.Observe(0.05)
+	h.Observe(0.1) // bounds are inclusive
+	h.Observe(0.5)
+	h.Observe(3)
+
+	bounds, cumulative, sum, count := h.Snapshot()
+	assert.Equal(t, []float64{0.1, 1}, bounds)
+	assert.Equal(t, []uint64{2, 3}, cumulative)
+	assert.InDelta(t, 3.65, sum, 1e-9)
+	assert.Equal(t, uint64(4), count)
+}
+
+func TestHistogram_Concurrent(t *testing.T) {
+	h := NewHistogram(DefaultBuckets)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				h.Observe(0.001)
+			}
+		}()
+	}
+	wg.Wait()
+
+	_, cumulative, sum, count := h.Snapshot()
+	assert.Equal(t, uint64(8000), count)
+	assert.Equal(t, uint64(8000), cumulative[0])
+	assert.InDelta(t, 8.0, sum, 1e-6)
+}
+
+func TestRegistry(t *testing.T) {
+	r := New()
+
+	r.Logged(types.LevelInfo)
+	r.Logged(types.LevelInfo)
+	r.Logged(types.LevelFatal)
+	r.Logged(types.Level(99)) // ignored
+	assert.Equal(t, uint64(2), r.LoggedCount(types.LevelInfo))
+	assert.Equal(t, uint64(1), r.LoggedCount(types.LevelFatal))
+	assert.Equal(t, uint64(0), r.LoggedCount(types.Level(99)))
+
+	loki := r.Transport("loki")
+	assert.Same(t, loki, r.Transport("loki"))
+	loki.Written.Add(3)
+	loki.ObservePush(20 * time.Millisecond)
+	r.Transport("console").Written.Inc()
+
+	assert.Equal(t, []string{"console", "loki"}, r.TransportNames())
+	assert.Equal(t, uint64(3), r.Transport("loki").Written.Load())
+	_, _, _, count := loki.PushLatency.Snapshot()
+	assert.Equal(t, uint64(1), count)
+}
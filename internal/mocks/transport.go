@@ -64,6 +64,9 @@ func (m *MockTransport) Write(ctx context.Context, entries ...*types.Entry) erro
 
 		maps.Copy(entryCopy.Fields, entry.Fields)
 		maps.Copy(entryCopy.Labels, entry.Labels)
+		if entry.Metadata != nil {
+			entryCopy.Metadata = maps.Clone(entry.Metadata)
+		}
 
 		m.entries = append(m.entries, entryCopy)
 	}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+const (
+	// maxIdleBuckets is the number of tracked streams above which idle buckets are pruned.
+	// Streams are expected to be low cardinality, so this is only a safety net.
+	maxIdleBuckets = 10000
+
+	// idleTimeout is how long a bucket must be unused before it can be pruned.
+	idleTimeout = time.Minute
+)
+
+// Limiter is a token-bucket rate limiter keyed by Loki stream.
+// Each stream gets its own bucket for lines and bytes, refilled continuously
+// at the configured rate with a capacity of one second worth of tokens.
+// Limiter is safe for concurrent use.
+type Limiter struct {
+	linesPerSecond float64
+	bytesPerSecond float64
+	buckets        map[string]*bucket
+	now            func() time.Time
+	mu             sync.Mutex
+}
+
+// bucket holds the available tokens for a single stream.
+type bucket struct {
+	lines    float64
+	bytes    float64
+	lastSeen time.Time
+}
+
+// New creates a limiter allowing linesPerSecond lines and bytesPerSecond bytes per stream.
+// A zero value disables the corresponding limit.
+func New(linesPerSecond, bytesPerSecond float64) *Limiter {
+	return &Limiter{
+		linesPerSecond: linesPerSecond,
+		bytesPerSecond: bytesPerSecond,
+		buckets:        make(map[string]*bucket),
+		now:            time.Now,
+	}
+}
+
+// Allow reports whether an entry of the given size may be sent on the stream identified by key.
+// Tokens are only consumed when the entry is allowed.
+func (l *Limiter) Allow(key string, size int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	b, exists := l.buckets[key]
+	if !exists {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.pruneLocked(now)
+		}
+		b = &bucket{lines: l.linesPerSecond, bytes: l.bytesPerSecond, lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	// Refill proportionally to the time elapsed, capped at one second of tokens
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.lastSeen = now
+	b.lines = min(l.linesPerSecond, b.lines+elapsed*l.linesPerSecond)
+	b.bytes = min(l.bytesPerSecond, b.bytes+elapsed*l.bytesPerSecond)
+
+	if l.linesPerSecond > 0 && b.lines < 1 {
+		return false
+	}
+	// An entry larger than the whole bucket is allowed once the bucket is full,
+	// otherwise it could never be sent.
+	if l.bytesPerSecond > 0 && b.bytes < min(float64(size), l.bytesPerSecond) {
+		return false
+	}
+
+	if l.linesPerSecond > 0 {
+		b.lines--
+	}
+	if l.bytesPerSecond > 0 {
+		b.bytes -= float64(size)
+	}
+
+	return true
+}
+
+// pruneLocked removes buckets that have not been used recently. Caller must hold l.mu.
+func (l *Limiter) pruneLocked(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// EntrySize returns the approximate number of bytes an entry occupies in a Loki log line.
+// It is intentionally cheap to compute: message length plus the length of each field key and value.
+func EntrySize(entry *types.Entry) int {
+	size := len(entry.Message)
+	for k, v := range entry.Fields {
+		size += len(k)
+		if s, ok := v.(string); ok {
+			size += len(s)
+		} else {
+			size += len(fmt.Sprint(v))
+		}
+	}
+	return size
+}
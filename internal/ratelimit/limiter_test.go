@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter(lines, bytes float64) (*Limiter, *time.Time) {
+	now := time.Unix(1000, 0)
+	l := New(lines, bytes)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_Lines(t *testing.T) {
+	l, now := newTestLimiter(2, 0)
+
+	assert.True(t, l.Allow("a", 10))
+	assert.True(t, l.Allow("a", 10))
+	assert.False(t, l.Allow("a", 10))
+
+	// Other streams have their own bucket
+	assert.True(t, l.Allow("b", 10))
+
+	// Tokens are refilled over time
+	*now = now.Add(500 * time.Millisecond)
+	assert.True(t, l.Allow("a", 10))
+	assert.False(t, l.Allow("a", 10))
+
+	// Refill is capped at one second of tokens
+	*now = now.Add(10 * time.Second)
+	assert.True(t, l.Allow("a", 10))
+	assert.True(t, l.Allow("a", 10))
+	assert.False(t, l.Allow("a", 10))
+}
+
+func TestLimiter_Bytes(t *testing.T) {
+	l, now := newTestLimiter(0, 100)
+
+	assert.True(t, l.Allow("a", 60))
+	assert.False(t, l.Allow("a", 60))
+	assert.True(t, l.Allow("a", 40))
+	assert.False(t, l.Allow("a", 1))
+
+	// Oversized entries pass once the bucket is full
+	*now = now.Add(time.Second)
+	assert.True(t, l.Allow("a", 500))
+	assert.False(t, l.Allow("a", 1))
+}
+
+func TestLimiter_Prune(t *testing.T) {
+	l, now := newTestLimiter(1, 0)
+	l.buckets["stale"] = &bucket{lastSeen: now.Add(-2 * idleTimeout)}
+	l.buckets["fresh"] = &bucket{lastSeen: *now}
+
+	l.pruneLocked(*now)
+
+	assert.NotContains(t, l.buckets, "stale")
+	assert.Contains(t, l.buckets, "fresh")
+}
+
+func TestEntrySize(t *testing.T) {
+	entry := &types.Entry{
+		Message: "hello",
+		Fields:  map[string]any{"user": "bob", "n": 42},
+	}
+	assert.Equal(t, len("hello")+len("user")+len("bob")+len("n")+len("42"), EntrySize(entry))
+}
@@ -0,0 +1,146 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule describes what to redact and how.
+type Rule struct {
+	// Keys are field names whose values are always redacted (case-insensitive, at any depth).
+	Keys []string
+
+	// Patterns are matched against string values and the message; matches are redacted.
+	Patterns []*regexp.Regexp
+
+	// Match optionally confirms that a pattern match is sensitive; matches it rejects are kept.
+	Match func(string) bool
+
+	// Replace returns the redacted form of a sensitive value.
+	Replace func(string) string
+}
+
+// Redactor applies a set of rules to log messages and fields.
+// It never mutates its input: redacted maps and slices are copies.
+// Redactor is safe for concurrent use.
+type Redactor struct {
+	keys     map[string]func(string) string
+	patterns []pattern
+}
+
+// pattern is a compiled value pattern with its replacement strategy.
+type pattern struct {
+	re      *regexp.Regexp
+	replace func(string) string
+}
+
+// New creates a redactor from the given rules.
+// When several rules deny the same key, the first one wins.
+func New(rules ...Rule) *Redactor {
+	r := &Redactor{keys: make(map[string]func(string) string)}
+
+	for _, rule := range rules {
+		for _, k := range rule.Keys {
+			k = strings.ToLower(k)
+			if _, exists := r.keys[k]; !exists {
+				r.keys[k] = rule.Replace
+			}
+		}
+		replace := rule.Replace
+		if match := rule.Match; match != nil {
+			redact := rule.Replace
+			replace = func(s string) string {
+				if !match(s) {
+					return s
+				}
+				return redact(s)
+			}
+		}
+		for _, re := range rule.Patterns {
+			r.patterns = append(r.patterns, pattern{re: re, replace: replace})
+		}
+	}
+
+	return r
+}
+
+// Message scrubs sensitive substrings from a log message.
+func (r *Redactor) Message(message string) string {
+	return r.scrub(message)
+}
+
+// Fields returns a copy of fields with denied keys and sensitive string values redacted.
+func (r *Redactor) Fields(fields map[string]any) map[string]any {
+	if fields == nil {
+		return nil
+	}
+
+	out := make(map[string]any, len(fields))
+	for k, v := range fields {
+		out[k] = r.field(k, v)
+	}
+
+	return out
+}
+
+// Strings returns a copy of a string map, such as structured metadata, with denied keys
+// and sensitive values redacted.
+func (r *Redactor) Strings(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	return r.value(values).(map[string]string)
+}
+
+// field redacts a single value, replacing it entirely when its key is denied.
+func (r *Redactor) field(key string, value any) any {
+	if replace, denied := r.keys[strings.ToLower(key)]; denied {
+		if s, ok := value.(string); ok {
+			return replace(s)
+		}
+		return replace(fmt.Sprint(value))
+	}
+
+	return r.value(value)
+}
+
+// value walks nested maps and slices, scrubbing strings with the value patterns.
+func (r *Redactor) value(value any) any {
+	switch v := value.(type) {
+	case string:
+		return r.scrub(v)
+	case map[string]any:
+		return r.Fields(v)
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for k, s := range v {
+			if redacted, ok := r.field(k, s).(string); ok {
+				out[k] = redacted
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = r.value(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = r.scrub(s)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// scrub replaces every pattern match in s.
+func (r *Redactor) scrub(s string) string {
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, p.replace)
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package redact
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func replaceWith(s string) func(string) string {
+	return func(string) string { return s }
+}
+
+func TestRedactor_Fields(t *testing.T) {
+	r := New(Rule{
+		Keys:    []string{"password", "Authorization"},
+		Replace: replaceWith("[REDACTED]"),
+	}, Rule{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`\d{4}-\d{4}`)},
+		Replace:  replaceWith("####"),
+	})
+
+	fields := map[string]any{
+		"PASSWORD": "hunter2",
+		"user":     "bob",
+		"note":     "card 1234-5678 used",
+		"count":    3,
+		"request": map[string]any{
+			"authorization": "Bearer abc",
+			"tags":          []any{"ok", "1111-2222"},
+		},
+		"headers": map[string]string{"Authorization": "Basic xyz", "Accept": "json"},
+		"ids":     []string{"9999-0000"},
+		"token":   12345,
+	}
+
+	out := r.Fields(fields)
+
+	assert.Equal(t, "[REDACTED]", out["PASSWORD"])
+	assert.Equal(t, "bob", out["user"])
+	assert.Equal(t, "card #### used", out["note"])
+	assert.Equal(t, 3, out["count"])
+	assert.Equal(t, 12345, out["token"])
+
+	request := out["request"].(map[string]any)
+	assert.Equal(t, "[REDACTED]", request["authorization"])
+	assert.Equal(t, []any{"ok", "####"}, request["tags"])
+	assert.Equal(t, map[string]string{"Authorization": "[REDACTED]", "Accept": "json"}, out["headers"])
+	assert.Equal(t, []string{"####"}, out["ids"])
+
+	// Input is left untouched
+	assert.Equal(t, "hunter2", fields["PASSWORD"])
+	assert.Equal(t, "Bearer abc", fields["request"].(map[string]any)["authorization"])
+
+	assert.Nil(t, r.Fields(nil))
+}
+
+func TestRedactor_Strings(t *testing.T) {
+	r := New(Rule{Keys: []string{"session"}, Replace: replaceWith("[REDACTED]")}, Rule{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`\d{4}-\d{4}`)},
+		Replace:  replaceWith("####"),
+	})
+
+	out := r.Strings(map[string]string{"Session": "s3cr3t", "note": "card 1234-5678", "user": "bob"})
+	assert.Equal(t, map[string]string{"Session": "[REDACTED]", "note": "card ####", "user": "bob"}, out)
+	assert.Nil(t, r.Strings(nil))
+}
+
+func TestRedactor_NonStringDeniedValue(t *testing.T) {
+	r := New(Rule{Keys: []string{"secret"}, Replace: func(s string) string { return "<" + s + ">" }})
+
+	out := r.Fields(map[string]any{"secret": map[string]any{"a": 1}})
+	assert.Equal(t, "<map[a:1]>", out["secret"])
+}
+
+func TestRedactor_Message(t *testing.T) {
+	r := New(Rule{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`[a-z]+@example\.com`)},
+		Replace:  replaceWith("[EMAIL]"),
+	})
+
+	assert.Equal(t, "login from [EMAIL] ok", r.Message("login from bob@example.com ok"))
+	assert.Equal(t, "nothing here", r.Message("nothing here"))
+}
+
+func TestRedactor_Match(t *testing.T) {
+	r := New(Rule{
+		Keys:     []string{"pin"},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`\d{4}`)},
+		Match:    func(s string) bool { return s != "0000" },
+		Replace:  replaceWith("####"),
+	})
+
+	// Matches rejected by Match are kept; denied keys are always redacted
+	assert.Equal(t, "codes #### and 0000", r.Message("codes 1234 and 0000"))
+	assert.Equal(t, "####", r.Fields(map[string]any{"pin": "0000"})["pin"])
+}
@@ -3,10 +3,12 @@ package transport
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/edaniel30/loki-logger-go/types"
 )
@@ -14,29 +16,156 @@ import (
 // ANSI color codes for console output
 const (
 	colorReset   = "\033[0m"
-	colorCyan    = "\033[36m" // Debug
-	colorGreen   = "\033[32m" // Info
-	colorYellow  = "\033[33m" // Warn
-	colorRed     = "\033[31m" // Error
-	colorMagenta = "\033[35m" // Fatal
+	colorCyan    = "\033[36m"   // Debug
+	colorGreen   = "\033[32m"   // Info
+	colorYellow  = "\033[33m"   // Warn
+	colorRed     = "\033[31m"   // Error
+	colorBoldRed = "\033[1;31m" // Panic
+	colorMagenta = "\033[35m"   // Fatal
 )
 
-// stdoutMu serializes all writes to os.Stdout across every ConsoleTransport instance.
+// consoleMu serializes all writes across every ConsoleTransport instance.
 // This prevents interleaved output when multiple instances write concurrently
 // (e.g. the logger's transport and an OnFlushErrorConsole callback).
-var stdoutMu sync.Mutex
+var consoleMu sync.Mutex
 
-// ConsoleTransport writes log entries to stdout with colored output.
+// DefaultTimeLayout is the timestamp layout of the human format.
+const DefaultTimeLayout = "2006-01-02 15:04:05"
+
+// ConsoleFormat is the output format of a ConsoleTransport.
+type ConsoleFormat int
+
+const (
+	// FormatHuman writes colored, human-readable lines.
+	FormatHuman ConsoleFormat = iota
+	// FormatJSON writes one JSON object per line with the fields of the Loki log line,
+	// plus the timestamp, labels and structured metadata of the entry.
+	FormatJSON
+	// FormatLogfmt writes logfmt lines, quoting and escaping values as needed.
+	FormatLogfmt
+)
+
+// ColorMode determines whether the human format uses ANSI colors.
+type ColorMode int
+
+const (
+	// ColorAuto uses colors when the writer is a terminal. NO_COLOR disables them
+	// and FORCE_COLOR enables them, whatever the writer.
+	ColorAuto ColorMode = iota
+	// ColorAlways always uses colors.
+	ColorAlways
+	// ColorNever never uses colors.
+	ColorNever
+)
+
+// ConsoleTransport writes log entries to stdout or to configured writers.
 // This is a minimal implementation with sensible defaults:
-// - Always writes to stdout
+// - Writes to stdout, optionally sending Warn and above to a separate writer
 // - Always includes timestamp
-// - Always includes colors
+// - Human-readable output, colored on terminals, or JSON or logfmt lines for log collectors
 // - Thread-safe for concurrent use
-type ConsoleTransport struct{}
+type ConsoleTransport struct {
+	format     ConsoleFormat
+	out        consoleOutput
+	errOut     consoleOutput // receives LevelWarn and above
+	timeLayout string
+	location   *time.Location // nil keeps the location of the entry timestamp
+}
+
+// consoleOutput is a writer and whether colors are written to it.
+type consoleOutput struct {
+	w     io.Writer // nil writes to os.Stdout
+	color bool
+}
+
+// writer returns the writer, resolving os.Stdout when it is written to
+// so that a replaced os.Stdout is honored.
+func (o consoleOutput) writer() io.Writer {
+	if o.w == nil {
+		return os.Stdout
+	}
+	return o.w
+}
+
+// ConsoleTransportConfig configures a ConsoleTransport instance.
+type ConsoleTransportConfig struct {
+	// Format is the output format (default: FormatHuman)
+	Format ConsoleFormat
+
+	// Writer receives the entries (default: os.Stdout)
+	Writer io.Writer
+
+	// ErrorWriter receives LevelWarn entries and above (default: Writer)
+	ErrorWriter io.Writer
+
+	// Color determines whether the human format uses colors (default: ColorAuto)
+	Color ColorMode
+
+	// TimeLayout is the timestamp layout of the human format (default: DefaultTimeLayout).
+	// JSON and logfmt always use RFC 3339.
+	TimeLayout string
+
+	// TimeLocation is the time zone timestamps are written in (default: local time)
+	TimeLocation *time.Location
+}
 
 // NewConsoleTransport creates a new console transport with default settings.
 func NewConsoleTransport() *ConsoleTransport {
-	return &ConsoleTransport{}
+	return NewConsoleTransportWithConfig(ConsoleTransportConfig{})
+}
+
+// NewConsoleTransportWithConfig creates a new console transport with the given settings.
+func NewConsoleTransportWithConfig(config ConsoleTransportConfig) *ConsoleTransport {
+	out := consoleOutput{w: config.Writer}
+	out.color = useColor(config.Color, out.writer())
+
+	errOut := out
+	if config.ErrorWriter != nil {
+		errOut = consoleOutput{w: config.ErrorWriter}
+		errOut.color = useColor(config.Color, errOut.w)
+	}
+
+	layout := config.TimeLayout
+	if layout == "" {
+		layout = DefaultTimeLayout
+	}
+
+	return &ConsoleTransport{
+		format:     config.Format,
+		out:        out,
+		errOut:     errOut,
+		timeLayout: layout,
+		location:   config.TimeLocation,
+	}
+}
+
+// useColor resolves the color mode for a writer. With ColorAuto, NO_COLOR takes
+// precedence over FORCE_COLOR, which takes precedence over terminal detection.
+func useColor(mode ColorMode, w io.Writer) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		return force != "0" && force != "false"
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a file attached to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
 func (ct *ConsoleTransport) Name() string {
@@ -44,13 +173,21 @@ func (ct *ConsoleTransport) Name() string {
 }
 
 func (ct *ConsoleTransport) Write(ctx context.Context, entries ...*types.Entry) error {
-	stdoutMu.Lock()
-	defer stdoutMu.Unlock()
+	consoleMu.Lock()
+	defer consoleMu.Unlock()
 
 	for _, entry := range entries {
-		formatted := ct.format(entry)
+		out := ct.out
+		if entry.Level.IsEnabled(types.LevelWarn) {
+			out = ct.errOut
+		}
 
-		if _, err := os.Stdout.WriteString(formatted); err != nil {
+		formatted, err := ct.formatEntry(entry, out.color)
+		if err != nil {
+			return fmt.Errorf("failed to format console entry: %w", err)
+		}
+
+		if _, err := io.WriteString(out.writer(), formatted); err != nil {
 			return fmt.Errorf("failed to write to console: %w", err)
 		}
 	}
@@ -58,16 +195,37 @@ func (ct *ConsoleTransport) Write(ctx context.Context, entries ...*types.Entry)
 	return nil
 }
 
-// format converts a log entry to text format with colors and timestamp.
-func (ct *ConsoleTransport) format(entry *types.Entry) string {
+// formatEntry converts a log entry to a line in the configured format.
+// color only applies to the human format.
+func (ct *ConsoleTransport) formatEntry(entry *types.Entry, color bool) (string, error) {
+	switch ct.format {
+	case FormatJSON:
+		return ct.formatJSON(entry)
+	case FormatLogfmt:
+		return ct.formatLogfmt(entry), nil
+	default:
+		return ct.formatHuman(entry, color), nil
+	}
+}
+
+// timestamp returns the entry timestamp in the configured time zone.
+func (ct *ConsoleTransport) timestamp(entry *types.Entry) time.Time {
+	if ct.location == nil {
+		return entry.Timestamp
+	}
+	return entry.Timestamp.In(ct.location)
+}
+
+// formatHuman converts a log entry to text format with timestamp and, if color is set, colors.
+func (ct *ConsoleTransport) formatHuman(entry *types.Entry, color bool) string {
 	var b strings.Builder
 
 	// Timestamp (always included)
-	b.WriteString(entry.Timestamp.Format("2006-01-02 15:04:05"))
+	b.WriteString(ct.timestamp(entry).Format(ct.timeLayout))
 	b.WriteString(" ")
 
-	// Level with color
-	b.WriteString(ct.formatLevel(entry.Level))
+	// Level, colored if enabled
+	b.WriteString(ct.formatLevel(entry.Level, color))
 	b.WriteString(" ")
 
 	// Labels as metadata (system context)
@@ -85,32 +243,43 @@ func (ct *ConsoleTransport) format(entry *types.Entry) string {
 		b.WriteString(ct.formatFields(entry.Fields))
 	}
 
+	// Structured metadata (if any) - shown like fields, sorted alphabetically
+	if len(entry.Metadata) > 0 {
+		b.WriteString(" ")
+		b.WriteString(ct.formatLabels(entry.Metadata))
+	}
+
 	b.WriteString("\n")
 
 	return b.String()
 }
 
-// formatLevel returns a colored level string.
-func (ct *ConsoleTransport) formatLevel(level types.Level) string {
+// formatLevel returns the level string, colored if color is set.
+func (ct *ConsoleTransport) formatLevel(level types.Level, color bool) string {
 	levelStr := strings.ToUpper(level.String())
+	if !color {
+		return "[" + levelStr + "]"
+	}
 
-	var color string
+	var code string
 	switch level {
 	case types.LevelDebug:
-		color = colorCyan
+		code = colorCyan
 	case types.LevelInfo:
-		color = colorGreen
+		code = colorGreen
 	case types.LevelWarn:
-		color = colorYellow
+		code = colorYellow
 	case types.LevelError:
-		color = colorRed
+		code = colorRed
+	case types.LevelPanic:
+		code = colorBoldRed
 	case types.LevelFatal:
-		color = colorMagenta
+		code = colorMagenta
 	default:
-		color = colorReset
+		code = colorReset
 	}
 
-	return fmt.Sprintf("%s[%s]%s", color, levelStr, colorReset)
+	return fmt.Sprintf("%s[%s]%s", code, levelStr, colorReset)
 }
 
 // formatLabels formats labels as key=value pairs, sorted alphabetically.
@@ -156,13 +325,13 @@ func (ct *ConsoleTransport) formatFields(fields map[string]any) string {
 }
 
 // Flush returns nil because console writes are not buffered.
-// Logs are written immediately to stdout in Write().
+// Logs are written immediately to the writers in Write().
 func (ct *ConsoleTransport) Flush(ctx context.Context) error {
 	return nil
 }
 
 // Close returns nil because console transport has no resources to release.
-// stdout is managed by the OS and should not be closed.
+// The writers are owned by the caller (stdout by the OS) and are not closed.
 func (ct *ConsoleTransport) Close() error {
 	return nil
 }
@@ -0,0 +1,148 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// jsonReservedKeys are the keys formatJSON writes itself. Fields of the same name are
+// written with jsonFieldPrefix instead of being overwritten.
+var jsonReservedKeys = map[string]struct{}{"message": {}, "timestamp": {}, "labels": {}, "metadata": {}}
+
+// jsonFieldPrefix is prepended to field keys colliding with jsonReservedKeys.
+const jsonFieldPrefix = "fields."
+
+// formatJSON converts a log entry to a JSON line with the message and fields of the
+// Loki log line, plus "timestamp", "labels" and, when present, "metadata".
+// Fields named after one of these keys are written as "fields.<key>".
+func (ct *ConsoleTransport) formatJSON(entry *types.Entry) (string, error) {
+	data := make(map[string]any, len(entry.Fields)+4)
+	for k, v := range entry.Fields {
+		if _, reserved := jsonReservedKeys[k]; reserved {
+			k = jsonFieldPrefix + k
+		}
+		data[k] = v
+	}
+	data["message"] = entry.Message
+	data["timestamp"] = ct.timestamp(entry).Format(time.RFC3339Nano)
+	data["labels"] = entry.Labels
+	if len(entry.Metadata) > 0 {
+		data["metadata"] = entry.Metadata
+	}
+
+	line, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	return string(line) + "\n", nil
+}
+
+// formatLogfmt converts a log entry to a logfmt line: time, level and msg first, then
+// labels, fields and structured metadata, each sorted alphabetically. The "level" label
+// is not repeated.
+func (ct *ConsoleTransport) formatLogfmt(entry *types.Entry) string {
+	var b strings.Builder
+
+	writeLogfmtPair(&b, "time", ct.timestamp(entry).Format(time.RFC3339Nano))
+	writeLogfmtPair(&b, "level", entry.Level.String())
+	writeLogfmtPair(&b, "msg", entry.Message)
+
+	for _, k := range sortedKeys(entry.Labels) {
+		if k != "level" {
+			writeLogfmtPair(&b, k, entry.Labels[k])
+		}
+	}
+	for _, k := range sortedKeys(entry.Fields) {
+		writeLogfmtPair(&b, k, logfmtValue(entry.Fields[k]))
+	}
+	for _, k := range sortedKeys(entry.Metadata) {
+		writeLogfmtPair(&b, k, entry.Metadata[k])
+	}
+
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// writeLogfmtPair appends key=value to b, separated from the previous pair by a space.
+func writeLogfmtPair(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteString("=")
+	if needsQuoting(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+// logfmtKey replaces the characters a logfmt key cannot contain with underscores.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// needsQuoting reports whether a logfmt value must be quoted: when it is empty or
+// contains spaces, '=', quotes, or non-printable characters.
+func needsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// logfmtValue formats a field value. Scalars, errors and fmt.Stringer values are printed
+// as text; maps, slices and structs are encoded as JSON.
+func logfmtValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// sortedKeys returns the keys of m in alphabetical order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,12 +2,17 @@ package transport
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/edaniel30/loki-logger-go/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConsoleTransport(t *testing.T) {
@@ -17,11 +22,13 @@ func TestConsoleTransport(t *testing.T) {
 	assert.Equal(t, "console", ct.Name())
 
 	// Test formatLevel for all levels
-	assert.Equal(t, colorCyan+"[DEBUG]"+colorReset, ct.formatLevel(types.LevelDebug))
-	assert.Equal(t, colorGreen+"[INFO]"+colorReset, ct.formatLevel(types.LevelInfo))
-	assert.Equal(t, colorYellow+"[WARN]"+colorReset, ct.formatLevel(types.LevelWarn))
-	assert.Equal(t, colorRed+"[ERROR]"+colorReset, ct.formatLevel(types.LevelError))
-	assert.Equal(t, colorMagenta+"[FATAL]"+colorReset, ct.formatLevel(types.LevelFatal))
+	assert.Equal(t, colorCyan+"[DEBUG]"+colorReset, ct.formatLevel(types.LevelDebug, true))
+	assert.Equal(t, colorGreen+"[INFO]"+colorReset, ct.formatLevel(types.LevelInfo, true))
+	assert.Equal(t, colorYellow+"[WARN]"+colorReset, ct.formatLevel(types.LevelWarn, true))
+	assert.Equal(t, colorRed+"[ERROR]"+colorReset, ct.formatLevel(types.LevelError, true))
+	assert.Equal(t, colorBoldRed+"[PANIC]"+colorReset, ct.formatLevel(types.LevelPanic, true))
+	assert.Equal(t, colorMagenta+"[FATAL]"+colorReset, ct.formatLevel(types.LevelFatal, true))
+	assert.Equal(t, "[WARN]", ct.formatLevel(types.LevelWarn, false))
 
 	// Test formatLabels
 	assert.Equal(t, "", ct.formatLabels(map[string]string{}))
@@ -67,7 +74,7 @@ func TestConsoleTransport(t *testing.T) {
 		Labels:    map[string]string{"app": "test", "env": "prod"},
 		Fields:    map[string]any{"user": "john"},
 	}
-	result = ct.format(entry)
+	result = ct.formatHuman(entry, true)
 	assert.Contains(t, result, "2024-01-15 10:30:45")
 	assert.Contains(t, result, "[INFO]")
 	assert.Contains(t, result, "app=test")
@@ -76,6 +83,11 @@ func TestConsoleTransport(t *testing.T) {
 	assert.Contains(t, result, "user=john")
 	assert.True(t, strings.HasSuffix(result, "\n"))
 
+	// Test format with structured metadata
+	entry.Metadata = map[string]string{"user_id": "42", "tenant": "acme"}
+	result = ct.formatHuman(entry, true)
+	assert.Contains(t, result, "user=john tenant=acme user_id=42")
+
 	// Test format without fields
 	entry = &types.Entry{
 		Level:     types.LevelError,
@@ -83,14 +95,181 @@ func TestConsoleTransport(t *testing.T) {
 		Timestamp: time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC),
 		Fields:    map[string]any{},
 	}
-	result = ct.format(entry)
+	result = ct.formatHuman(entry, true)
 	assert.Contains(t, result, "2024-01-15 10:30:45")
 	assert.Contains(t, result, "[ERROR]")
 	assert.Contains(t, result, "error occurred")
 	assert.True(t, strings.HasSuffix(result, "\n"))
 
 	ctx := context.Background()
+	ct = NewConsoleTransportWithConfig(ConsoleTransportConfig{Writer: io.Discard})
 	assert.NoError(t, ct.Write(ctx, entry))
 	assert.NoError(t, ct.Flush(ctx))
 	assert.NoError(t, ct.Close())
 }
+
+func TestConsoleTransport_FormatJSON(t *testing.T) {
+	ct := NewConsoleTransportWithConfig(ConsoleTransportConfig{Format: FormatJSON})
+	entry := &types.Entry{
+		Level:     types.LevelWarn,
+		Message:   "slow query",
+		Timestamp: time.Date(2024, 1, 15, 10, 30, 45, 500, time.UTC),
+		Labels:    types.Labels{"app": "test", "level": "warn"},
+		Fields:    map[string]any{"rows": 1200, "error": map[string]any{"message": "timeout"}},
+		Metadata:  map[string]string{"trace_id": "abc"},
+	}
+
+	line, err := ct.formatEntry(entry, false)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(line, "\n"))
+	assert.Equal(t, 1, strings.Count(line, "\n"))
+
+	var data map[string]any
+	require.NoError(t, json.Unmarshal([]byte(line), &data))
+	assert.Equal(t, "slow query", data["message"])
+	assert.Equal(t, "2024-01-15T10:30:45.0000005Z", data["timestamp"])
+	assert.Equal(t, map[string]any{"app": "test", "level": "warn"}, data["labels"])
+	assert.Equal(t, map[string]any{"trace_id": "abc"}, data["metadata"])
+	assert.Equal(t, float64(1200), data["rows"])
+	assert.Equal(t, map[string]any{"message": "timeout"}, data["error"])
+
+	// Fields named after reserved keys are prefixed instead of overwritten
+	entry.Fields = map[string]any{"timestamp": "from-field", "message": "m", "labels": 1, "metadata": true}
+	line, err = ct.formatEntry(entry, false)
+	require.NoError(t, err)
+	data = nil
+	require.NoError(t, json.Unmarshal([]byte(line), &data))
+	assert.Equal(t, "2024-01-15T10:30:45.0000005Z", data["timestamp"])
+	assert.Equal(t, "slow query", data["message"])
+	assert.Equal(t, "from-field", data["fields.timestamp"])
+	assert.Equal(t, "m", data["fields.message"])
+	assert.Equal(t, float64(1), data["fields.labels"])
+	assert.Equal(t, true, data["fields.metadata"])
+
+	// Unencodable fields are reported
+	entry.Fields = map[string]any{"fn": func() {}}
+	_, err = ct.formatEntry(entry, false)
+	assert.Error(t, err)
+	assert.Error(t, ct.Write(context.Background(), entry))
+}
+
+func TestConsoleTransport_FormatLogfmt(t *testing.T) {
+	ct := NewConsoleTransportWithConfig(ConsoleTransportConfig{Format: FormatLogfmt})
+	entry := &types.Entry{
+		Level:     types.LevelError,
+		Message:   `payment "failed"`,
+		Timestamp: time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC),
+		Labels:    types.Labels{"app": "test", "level": "error"},
+		Fields: map[string]any{
+			"amount":  9.99,
+			"empty":   "",
+			"err":     errors.New("card declined"),
+			"ids":     []int{1, 2},
+			"nil":     nil,
+			"path":    `C:\tmp`,
+			"query":   "a=b",
+			"trace":   "line1\nline2",
+			"bad key": "x",
+		},
+		Metadata: map[string]string{"user_id": "42"},
+	}
+
+	line, err := ct.formatEntry(entry, false)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`time=2024-01-15T10:30:45Z level=error msg="payment \"failed\"" app=test `+
+			`amount=9.99 bad_key=x empty="" err="card declined" ids=[1,2] nil=null `+
+			`path="C:\\tmp" query="a=b" trace="line1\nline2" user_id=42`+"\n",
+		line)
+}
+
+func TestLogfmtValue(t *testing.T) {
+	assert.Equal(t, "42", logfmtValue(42))
+	assert.Equal(t, "true", logfmtValue(true))
+	assert.Equal(t, "1s", logfmtValue(time.Second))
+	assert.Equal(t, `{"a":1}`, logfmtValue(map[string]int{"a": 1}))
+	assert.Equal(t, "_", logfmtKey(""))
+	assert.False(t, needsQuoting("plain-value_1.0"))
+	assert.True(t, needsQuoting("é\u00a0"))
+}
+
+func TestConsoleTransport_Writers(t *testing.T) {
+	var out, errOut strings.Builder
+	ct := NewConsoleTransportWithConfig(ConsoleTransportConfig{
+		Writer:      &out,
+		ErrorWriter: &errOut,
+		Color:       ColorNever,
+	})
+	ctx := context.Background()
+	ts := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+
+	require.NoError(t, ct.Write(ctx,
+		&types.Entry{Level: types.LevelInfo, Message: "started", Timestamp: ts},
+		&types.Entry{Level: types.LevelWarn, Message: "slow", Timestamp: ts},
+		&types.Entry{Level: types.LevelError, Message: "failed", Timestamp: ts},
+	))
+	assert.Equal(t, "2024-01-15 10:30:45 [INFO] started\n", out.String())
+	assert.Equal(t, "2024-01-15 10:30:45 [WARN] slow\n2024-01-15 10:30:45 [ERROR] failed\n", errOut.String())
+
+	// Without an error writer, everything goes to the writer
+	out.Reset()
+	ct = NewConsoleTransportWithConfig(ConsoleTransportConfig{Writer: &out, Color: ColorNever})
+	require.NoError(t, ct.Write(ctx, &types.Entry{Level: types.LevelError, Message: "failed", Timestamp: ts}))
+	assert.Contains(t, out.String(), "[ERROR] failed")
+
+	// Defaults to stdout
+	ct = NewConsoleTransport()
+	assert.Equal(t, os.Stdout, ct.out.writer())
+	assert.Equal(t, os.Stdout, ct.errOut.writer())
+	assert.Equal(t, DefaultTimeLayout, ct.timeLayout)
+}
+
+func TestConsoleTransport_Time(t *testing.T) {
+	var out strings.Builder
+	tokyo := time.FixedZone("JST", 9*60*60)
+	ct := NewConsoleTransportWithConfig(ConsoleTransportConfig{
+		Writer:       &out,
+		Color:        ColorNever,
+		TimeLayout:   time.RFC3339,
+		TimeLocation: tokyo,
+	})
+
+	entry := &types.Entry{Level: types.LevelInfo, Message: "started", Timestamp: time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)}
+	require.NoError(t, ct.Write(context.Background(), entry))
+	assert.Equal(t, "2024-01-15T19:30:45+09:00 [INFO] started\n", out.String())
+
+	// JSON and logfmt keep RFC 3339 but use the time zone
+	ct.format = FormatLogfmt
+	line, err := ct.formatEntry(entry, false)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(line, "time=2024-01-15T19:30:45+09:00 "))
+}
+
+func TestConsoleTransport_Color(t *testing.T) {
+	var out strings.Builder
+
+	assert.True(t, useColor(ColorAlways, &out))
+	assert.False(t, useColor(ColorNever, &out))
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	require.NoError(t, os.Unsetenv("FORCE_COLOR"))
+	assert.False(t, useColor(ColorAuto, &out), "not a terminal")
+	assert.False(t, isTerminal(&out))
+
+	t.Setenv("FORCE_COLOR", "1")
+	assert.True(t, useColor(ColorAuto, &out))
+
+	t.Setenv("FORCE_COLOR", "0")
+	assert.False(t, useColor(ColorAuto, &out))
+
+	t.Setenv("FORCE_COLOR", "1")
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, useColor(ColorAuto, &out), "NO_COLOR takes precedence")
+	assert.True(t, useColor(ColorAlways, &out), "explicit mode ignores the environment")
+
+	// Colors are resolved per writer
+	ct := NewConsoleTransportWithConfig(ConsoleTransportConfig{Writer: &out, Color: ColorAlways})
+	require.NoError(t, ct.Write(context.Background(), &types.Entry{Level: types.LevelInfo, Message: "colored"}))
+	assert.Contains(t, out.String(), colorGreen+"[INFO]"+colorReset)
+}
@@ -2,11 +2,14 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/edaniel30/loki-logger-go/internal/client"
+	"github.com/edaniel30/loki-logger-go/internal/metrics"
+	"github.com/edaniel30/loki-logger-go/internal/ratelimit"
 	"github.com/edaniel30/loki-logger-go/types"
 )
 
@@ -15,10 +18,14 @@ import (
 type LokiTransport struct {
 	client        *client.Client
 	buffer        []*types.Entry
+	bufferBytes   int // approximate size of the buffered entries, tracked only with maxBatchBytes
 	batchSize     int
+	maxBatchBytes int // 0 only flushes on batchSize
 	flushInterval time.Duration
 	timeout       time.Duration
 	onFlushError  func(error)
+	maxBuffered   int // entries kept while the circuit is open; 0 drops them
+	metrics       *metrics.Transport
 	mu            sync.Mutex
 	stopCh        chan struct{}
 	doneCh        chan struct{} // signals when background flusher is done
@@ -38,6 +45,10 @@ type LokiTransportConfig struct {
 	// BatchSize is the number of entries to batch before sending
 	BatchSize int
 
+	// MaxBatchBytes is the approximate encoded size in bytes that triggers a flush.
+	// Pushes larger than this are split into several requests. If 0, only BatchSize applies.
+	MaxBatchBytes int
+
 	// FlushInterval is how often to flush regardless of batch size
 	FlushInterval time.Duration
 
@@ -52,6 +63,35 @@ type LokiTransportConfig struct {
 	// triggered by Write. If nil, flush errors are silently discarded.
 	// The callback may be invoked concurrently and must be non-blocking.
 	OnFlushError func(error)
+
+	// OnRejected is an optional callback receiving the entries Loki refused with a 400 status.
+	// They are isolated from their batch, whose other entries are still pushed.
+	// If nil, rejections are reported through OnFlushError.
+	OnRejected func(entries []*types.Entry, err error)
+
+	// Metrics receives batch, push, retry and latency counters (optional)
+	Metrics *metrics.Transport
+
+	// CircuitBreaker configures a circuit breaker around pushes.
+	// It is enabled when FailureThreshold is greater than 0.
+	CircuitBreaker client.BreakerConfig
+
+	// MaxBufferedWhileOpen is the number of entries kept in the buffer when the
+	// circuit breaker rejects a flush. The oldest entries are dropped first.
+	// If 0, rejected entries are dropped and reported through OnFlushError.
+	MaxBufferedWhileOpen int
+
+	// MetadataKeys lists field keys sent as structured metadata instead of in the log line
+	MetadataKeys []string
+
+	// MaxLineSize is the maximum log line size in bytes; 0 disables the limit
+	MaxLineSize int
+
+	// LineStrategy determines how lines longer than MaxLineSize are shortened
+	LineStrategy client.LineStrategy
+
+	// StrictTimestamps makes timestamps strictly increasing per stream across pushes
+	StrictTimestamps bool
 }
 
 // NewLokiTransport creates a new Loki transport with the given configuration.
@@ -60,13 +100,42 @@ func NewLokiTransport(config *LokiTransportConfig) *LokiTransport {
 		client:        client.NewClient(config.LokiURL, config.LokiUsername, config.LokiPassword, config.Timeout, config.MaxRetries),
 		buffer:        make([]*types.Entry, 0, config.BatchSize),
 		batchSize:     config.BatchSize,
+		maxBatchBytes: config.MaxBatchBytes,
 		flushInterval: config.FlushInterval,
 		timeout:       config.Timeout,
 		onFlushError:  config.OnFlushError,
+		maxBuffered:   config.MaxBufferedWhileOpen,
+		metrics:       config.Metrics,
 		stopCh:        make(chan struct{}),
 		doneCh:        make(chan struct{}),
 	}
 
+	if config.Metrics != nil {
+		lt.client.SetMetrics(config.Metrics)
+	}
+
+	if len(config.MetadataKeys) > 0 {
+		lt.client.SetMetadataKeys(config.MetadataKeys)
+	}
+
+	lt.client.SetOnRejected(lt.rejectedHandler(config.OnRejected))
+
+	if config.MaxBatchBytes > 0 {
+		lt.client.SetMaxBatchBytes(config.MaxBatchBytes)
+	}
+
+	if config.StrictTimestamps {
+		lt.client.SetStrictTimestamps(true)
+	}
+
+	if config.MaxLineSize > 0 {
+		lt.client.SetLineLimit(config.MaxLineSize, config.LineStrategy)
+	}
+
+	if config.CircuitBreaker.FailureThreshold > 0 {
+		lt.client.SetBreaker(config.CircuitBreaker)
+	}
+
 	// Start background flusher
 	go lt.backgroundFlusher()
 
@@ -77,11 +146,18 @@ func (lt *LokiTransport) Name() string {
 	return "loki"
 }
 
-// Write adds entries to the buffer and flushes if batch size is reached.
+// Write adds entries to the buffer and flushes if batch size or the maximum batch bytes is reached.
 func (lt *LokiTransport) Write(ctx context.Context, entries ...*types.Entry) error {
 	lt.mu.Lock()
 	lt.buffer = append(lt.buffer, entries...)
-	shouldFlush := len(lt.buffer) >= lt.batchSize
+	lt.bufferBytes += lt.entriesSize(entries)
+	shouldFlush := len(lt.buffer) >= lt.batchSize || (lt.maxBatchBytes > 0 && lt.bufferBytes >= lt.maxBatchBytes)
+	if shouldFlush && lt.maxBuffered > 0 && lt.client.CircuitOpen() {
+		// The flush would fail fast: keep buffering, within the same limit as requeue,
+		// and let the background flusher probe Loki once the circuit timeout elapses
+		shouldFlush = false
+		lt.trimBuffer()
+	}
 	lt.mu.Unlock()
 
 	if shouldFlush {
@@ -101,12 +177,30 @@ func (lt *LokiTransport) Flush(ctx context.Context) error {
 
 	// Take ownership of current buffer and allocate new one
 	// This avoids race conditions by not reusing the underlying array
-	toSend := lt.buffer
+	toSend, toSendBytes := lt.buffer, lt.bufferBytes
 	lt.buffer = make([]*types.Entry, 0, lt.batchSize)
+	lt.bufferBytes = 0
 	lt.mu.Unlock()
 
 	// Send to Loki - no conversion needed, both use types.Entry
 	if err := lt.client.Push(ctx, toSend); err != nil {
+		// Only the entries of the failed requests are lost when the push was split
+		failed, failedBytes := toSend, toSendBytes
+		var partialErr *client.PartialError
+		if errors.As(err, &partialErr) {
+			failed, failedBytes = partialErr.Failed, lt.entriesSize(partialErr.Failed)
+		}
+
+		if lt.maxBuffered > 0 && errors.Is(err, client.ErrCircuitOpen) {
+			// Keep the entries until Loki recovers instead of losing them
+			lt.requeue(failed, failedBytes)
+			return fmt.Errorf("failed to push to Loki, entries kept in buffer: %w", err)
+		}
+
+		if lt.metrics != nil {
+			lt.metrics.Failed.Add(uint64(len(failed)))
+		}
+
 		err = fmt.Errorf("failed to push to Loki: %w", err)
 		if lt.onFlushError != nil {
 			lt.onFlushError(err)
@@ -117,6 +211,80 @@ func (lt *LokiTransport) Flush(ctx context.Context) error {
 	return nil
 }
 
+// rejectedHandler returns the callback receiving entries rejected by Loki.
+func (lt *LokiTransport) rejectedHandler(onRejected func([]*types.Entry, error)) func([]*types.Entry, error) {
+	if onRejected != nil {
+		return onRejected
+	}
+	return func(entries []*types.Entry, err error) {
+		if lt.onFlushError != nil {
+			lt.onFlushError(fmt.Errorf("loki rejected %d entries: %w", len(entries), err))
+		}
+	}
+}
+
+// requeue puts entries rejected by an open circuit, of the given approximate size,
+// back in front of the buffer, dropping the oldest entries beyond maxBuffered.
+func (lt *LokiTransport) requeue(entries []*types.Entry, size int) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	buffer := make([]*types.Entry, 0, len(entries)+len(lt.buffer))
+	buffer = append(buffer, entries...)
+	buffer = append(buffer, lt.buffer...)
+
+	lt.buffer = buffer
+	lt.bufferBytes += size
+	lt.trimBuffer()
+}
+
+// trimBuffer drops the oldest buffered entries beyond maxBuffered, keeping bufferBytes
+// up to date without measuring the whole buffer. Must be called with mu held.
+func (lt *LokiTransport) trimBuffer() {
+	dropped := len(lt.buffer) - lt.maxBuffered
+	if dropped <= 0 {
+		return
+	}
+
+	lt.bufferBytes -= lt.entriesSize(lt.buffer[:dropped])
+	lt.buffer = lt.buffer[dropped:]
+	if lt.metrics != nil {
+		lt.metrics.Failed.Add(uint64(dropped))
+	}
+}
+
+// entriesSize returns the approximate size of entries in a Loki push payload.
+// It returns 0 when MaxBatchBytes is disabled: bufferBytes only serves to enforce it,
+// and measuring every field of every entry is not free.
+func (lt *LokiTransport) entriesSize(entries []*types.Entry) int {
+	if lt.maxBatchBytes <= 0 {
+		return 0
+	}
+
+	size := 0
+	for _, entry := range entries {
+		size += ratelimit.EntrySize(entry)
+	}
+	return size
+}
+
+// CircuitState returns the state of the circuit breaker around pushes.
+func (lt *LokiTransport) CircuitState() client.BreakerState {
+	return lt.client.BreakerState()
+}
+
+// QueueDepth returns the number of entries buffered and not yet pushed.
+func (lt *LokiTransport) QueueDepth() int {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return len(lt.buffer)
+}
+
+// Ready checks whether the Loki server reports itself ready to accept pushes.
+func (lt *LokiTransport) Ready(ctx context.Context) error {
+	return lt.client.Ready(ctx)
+}
+
 // Close stops the background flusher and flushes remaining entries.
 // It waits up to the configured Timeout for graceful shutdown.
 func (lt *LokiTransport) Close() error {
@@ -2,11 +2,16 @@ package transport
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/edaniel30/loki-logger-go/internal/client"
+	"github.com/edaniel30/loki-logger-go/internal/metrics"
 	"github.com/edaniel30/loki-logger-go/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -99,6 +104,108 @@ func TestLokiTransport_BatchFlush(t *testing.T) {
 	assert.Equal(t, 0, bufferLen)
 }
 
+func TestLokiTransport_MaxBatchBytes(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	m := &metrics.Transport{PushLatency: metrics.NewHistogram(metrics.DefaultBuckets)}
+	lt := NewLokiTransport(&LokiTransportConfig{
+		LokiURL:       srv.URL,
+		BatchSize:     1000,
+		MaxBatchBytes: 250,
+		FlushInterval: 1 * time.Hour,
+		Timeout:       10 * time.Second,
+		Metrics:       m,
+	})
+	defer func() { _ = lt.Close() }()
+
+	entry := &types.Entry{
+		Level:     types.LevelInfo,
+		Message:   strings.Repeat("x", 100),
+		Timestamp: time.Now(),
+		Labels:    types.Labels{"app": "test"},
+	}
+
+	ctx := context.Background()
+	require.NoError(t, lt.Write(ctx, entry, entry))
+	assert.Equal(t, 2, lt.QueueDepth())
+	assert.Equal(t, int32(0), requests.Load())
+
+	// The third entry crosses the threshold; the push is split to stay under it
+	require.NoError(t, lt.Write(ctx, entry))
+	assert.Equal(t, 0, lt.QueueDepth())
+	assert.Greater(t, requests.Load(), int32(1))
+	assert.Equal(t, uint64(3), m.Pushed.Load())
+
+	// Entries are not measured when the limit is disabled
+	unlimited := NewLokiTransport(&LokiTransportConfig{
+		LokiURL:       srv.URL,
+		BatchSize:     1000,
+		FlushInterval: 1 * time.Hour,
+		Timeout:       10 * time.Second,
+	})
+	defer func() { _ = unlimited.Close() }()
+	require.NoError(t, unlimited.Write(ctx, entry, entry))
+	unlimited.mu.Lock()
+	assert.Zero(t, unlimited.bufferBytes)
+	unlimited.mu.Unlock()
+}
+
+func TestLokiTransport_Rejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "out-of-order") {
+			http.Error(w, "entry out of order", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	good := &types.Entry{Level: types.LevelInfo, Message: "ok", Timestamp: time.Now(), Labels: types.Labels{"app": "test"}}
+	bad := &types.Entry{Level: types.LevelInfo, Message: "out-of-order", Timestamp: time.Now(), Labels: types.Labels{"app": "test"}}
+
+	t.Run("callback receives rejected entries", func(t *testing.T) {
+		var rejected []*types.Entry
+		var flushErrors []error
+		lt := NewLokiTransport(&LokiTransportConfig{
+			LokiURL:       srv.URL,
+			BatchSize:     3,
+			FlushInterval: 1 * time.Hour,
+			Timeout:       10 * time.Second,
+			OnFlushError:  func(err error) { flushErrors = append(flushErrors, err) },
+			OnRejected:    func(entries []*types.Entry, err error) { rejected = append(rejected, entries...) },
+		})
+		defer func() { _ = lt.Close() }()
+
+		require.NoError(t, lt.Write(context.Background(), good, bad, good))
+		assert.Equal(t, []*types.Entry{bad}, rejected)
+		assert.Empty(t, flushErrors)
+	})
+
+	t.Run("reported through OnFlushError without callback", func(t *testing.T) {
+		var flushErrors []error
+		lt := NewLokiTransport(&LokiTransportConfig{
+			LokiURL:       srv.URL,
+			BatchSize:     2,
+			FlushInterval: 1 * time.Hour,
+			Timeout:       10 * time.Second,
+			OnFlushError:  func(err error) { flushErrors = append(flushErrors, err) },
+		})
+		defer func() { _ = lt.Close() }()
+
+		require.NoError(t, lt.Write(context.Background(), good, bad))
+		require.Len(t, flushErrors, 1)
+		assert.ErrorContains(t, flushErrors[0], "loki rejected 1 entries")
+		var statusErr *client.StatusError
+		assert.ErrorAs(t, flushErrors[0], &statusErr)
+	})
+}
+
 func TestLokiTransport_OnFlushError(t *testing.T) {
 	t.Run("callback is invoked on flush error", func(t *testing.T) {
 		srv := newErrorServer(t)
@@ -199,6 +306,126 @@ func TestLokiTransport_OnFlushError(t *testing.T) {
 	})
 }
 
+func TestLokiTransport_CircuitBreaker(t *testing.T) {
+	newEntry := func(msg string) *types.Entry {
+		return &types.Entry{
+			Level:     types.LevelInfo,
+			Message:   msg,
+			Timestamp: time.Now(),
+			Labels:    types.Labels{"app": "test"},
+			Fields:    map[string]any{},
+		}
+	}
+
+	t.Run("buffers entries while open", func(t *testing.T) {
+		srv := newErrorServer(t)
+		var flushErrors int
+		m := &metrics.Transport{PushLatency: metrics.NewHistogram(metrics.DefaultBuckets)}
+		lt := NewLokiTransport(&LokiTransportConfig{
+			LokiURL:              srv.URL,
+			BatchSize:            100,
+			FlushInterval:        time.Hour,
+			MaxRetries:           0,
+			Timeout:              time.Second,
+			OnFlushError:         func(error) { flushErrors++ },
+			Metrics:              m,
+			CircuitBreaker:       client.BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour},
+			MaxBufferedWhileOpen: 3,
+			MaxBatchBytes:        1 << 20,
+		})
+		defer func() { _ = lt.Close() }()
+		ctx := context.Background()
+
+		// The first failure opens the circuit; that batch is lost
+		require.NoError(t, lt.Write(ctx, newEntry("lost")))
+		require.Error(t, lt.Flush(ctx))
+		assert.Equal(t, client.BreakerOpen, lt.CircuitState())
+		assert.Equal(t, 1, flushErrors)
+		assert.Equal(t, uint64(1), m.Failed.Load())
+
+		// While open, flushed entries stay in the buffer, oldest dropped beyond the limit
+		for _, msg := range []string{"a", "b", "c", "d"} {
+			require.NoError(t, lt.Write(ctx, newEntry(msg)))
+			err := lt.Flush(ctx)
+			require.ErrorIs(t, err, client.ErrCircuitOpen)
+		}
+		assert.Equal(t, 3, lt.QueueDepth())
+		assert.Equal(t, 1, flushErrors)
+		assert.Equal(t, uint64(2), m.Failed.Load())
+
+		lt.mu.Lock()
+		assert.Equal(t, "b", lt.buffer[0].Message)
+		assert.Equal(t, "d", lt.buffer[2].Message)
+		assert.Equal(t, lt.entriesSize(lt.buffer), lt.bufferBytes)
+		lt.mu.Unlock()
+	})
+
+	t.Run("writes do not flush while open", func(t *testing.T) {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+		var flushErrors int
+		lt := NewLokiTransport(&LokiTransportConfig{
+			LokiURL:              srv.URL,
+			BatchSize:            1,
+			FlushInterval:        time.Hour,
+			MaxRetries:           0,
+			Timeout:              time.Second,
+			OnFlushError:         func(error) { flushErrors++ },
+			CircuitBreaker:       client.BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour},
+			MaxBufferedWhileOpen: 3,
+			MaxBatchBytes:        1 << 20,
+		})
+		defer func() { _ = lt.Close() }()
+		ctx := context.Background()
+
+		// The first write flushes and opens the circuit
+		require.Error(t, lt.Write(ctx, newEntry("lost")))
+		assert.Equal(t, client.BreakerOpen, lt.CircuitState())
+
+		// Later writes past the batch size only buffer, within the limit
+		for _, msg := range []string{"a", "b", "c", "d"} {
+			require.NoError(t, lt.Write(ctx, newEntry(msg)))
+		}
+		assert.Equal(t, int32(1), requests.Load())
+		assert.Equal(t, 1, flushErrors)
+		assert.Equal(t, 3, lt.QueueDepth())
+
+		lt.mu.Lock()
+		assert.Equal(t, "b", lt.buffer[0].Message)
+		assert.Equal(t, lt.entriesSize(lt.buffer), lt.bufferBytes)
+		lt.mu.Unlock()
+	})
+
+	t.Run("drops entries while open without buffer", func(t *testing.T) {
+		srv := newErrorServer(t)
+		var flushErrors []error
+		lt := NewLokiTransport(&LokiTransportConfig{
+			LokiURL:        srv.URL,
+			BatchSize:      100,
+			FlushInterval:  time.Hour,
+			MaxRetries:     0,
+			Timeout:        time.Second,
+			OnFlushError:   func(err error) { flushErrors = append(flushErrors, err) },
+			CircuitBreaker: client.BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour},
+		})
+		defer func() { _ = lt.Close() }()
+		ctx := context.Background()
+
+		require.NoError(t, lt.Write(ctx, newEntry("first")))
+		require.Error(t, lt.Flush(ctx))
+		require.NoError(t, lt.Write(ctx, newEntry("second")))
+		require.ErrorIs(t, lt.Flush(ctx), client.ErrCircuitOpen)
+
+		assert.Equal(t, 0, lt.QueueDepth())
+		require.Len(t, flushErrors, 2)
+		assert.ErrorIs(t, flushErrors[1], client.ErrCircuitOpen)
+	})
+}
+
 func TestLokiTransport_Close(t *testing.T) {
 	config := LokiTransportConfig{
 		LokiURL:       "http://localhost:3100",
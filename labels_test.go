@@ -0,0 +1,85 @@
+package loki
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerLabelSanitize(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.labelRules = (&Config{LabelPolicy: LabelSanitize, MaxLabels: 6, MaxLabelValueLength: 8}).labelRules()
+	logger.config.Hooks = []Hook{HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+		entry.Labels["__tenant"] = "acme"
+		return true
+	})}
+
+	logger.WithLabels(types.Labels{"http-status": "200", "k8s.pod": "api-7d9f8c-x2x", "zone": "b"}).
+		Info(context.Background(), "test", nil)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, types.Labels{
+		"app":         "test-app",
+		"level":       "info",
+		"version":     "1.0.0",
+		"environment": "local",
+		"_tenant":     "acme",
+		"http_status": "200",
+	}, entries[0].Labels)
+}
+
+func TestLoggerLabelDrop(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.labelRules = (&Config{LabelPolicy: LabelDrop}).labelRules()
+
+	logger.WithLabels(types.Labels{"http-status": "200", "component": "db"}).Info(context.Background(), "test", nil)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "db", entries[0].Labels["component"])
+	assert.NotContains(t, entries[0].Labels, "http-status")
+	assert.NotContains(t, entries[0].Labels, "http_status")
+}
+
+func TestNewLabelReject(t *testing.T) {
+	_, err := New(newTestConfig(), WithLabelPolicy(LabelReject), WithLabels(types.Labels{"k8s.pod": "x"}))
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "Labels", configErr.Field)
+	assert.Contains(t, configErr.Message, `label "k8s.pod"`)
+
+	_, err = New(newTestConfig(), WithLabelPolicy(LabelReject), WithLabels(types.Labels{"pod": "x"}))
+	assert.NoError(t, err)
+
+	// Labels with other policies are fixed later instead
+	_, err = New(newTestConfig(), WithLabels(types.Labels{"k8s.pod": "x"}))
+	assert.NoError(t, err)
+}
+
+func TestLoggerWithCheckedLabels(t *testing.T) {
+	logger := newTestLogger(t)
+	logger.labelRules = (&Config{MaxLabels: 6, MaxLabelValueLength: 16}).labelRules()
+
+	child, err := logger.WithCheckedLabels(types.Labels{"component": "db"})
+	require.NoError(t, err)
+	assert.Equal(t, "db", child.config.Labels["component"])
+
+	_, err = logger.WithCheckedLabels(types.Labels{"http-status": "200"})
+	var labelErr *LabelError
+	require.ErrorAs(t, err, &labelErr)
+	assert.Equal(t, "http-status", labelErr.Label)
+
+	_, err = logger.WithCheckedLabels(types.Labels{"query": strings.Repeat("x", 17)})
+	require.ErrorAs(t, err, &labelErr)
+	assert.Contains(t, labelErr.Message, "longer than 16 bytes")
+
+	// Parent labels count towards the limit, together with the system labels
+	_, err = child.WithCheckedLabels(types.Labels{"region": "eu", "zone": "b"})
+	require.ErrorAs(t, err, &labelErr)
+	assert.Equal(t, "7 labels exceed the maximum of 6", labelErr.Message)
+}
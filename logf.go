@@ -0,0 +1,89 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// Enabled reports whether entries at the given level are logged, so that callers can skip
+// building expensive messages or fields for disabled levels.
+//
+// Example:
+//
+//	if logger.Enabled(types.LevelDebug) {
+//		logger.Debug(ctx, "cache state", map[string]any{"entries": cache.Dump()})
+//	}
+func (l *Logger) Enabled(level types.Level) bool {
+	return level.IsEnabled(l.config.LogLevel)
+}
+
+// Debugf logs a formatted message at debug level. The message is only formatted
+// when the debug level is enabled.
+func (l *Logger) Debugf(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, types.LevelDebug, format, args)
+}
+
+// Infof logs a formatted message at info level.
+func (l *Logger) Infof(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, types.LevelInfo, format, args)
+}
+
+// Warnf logs a formatted message at warning level.
+func (l *Logger) Warnf(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, types.LevelWarn, format, args)
+}
+
+// Errorf logs a formatted message at error level.
+// To attach the error itself as structured data, use Error with ErrorField instead.
+func (l *Logger) Errorf(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, types.LevelError, format, args)
+}
+
+// logf formats the message only if the level is enabled.
+func (l *Logger) logf(ctx context.Context, level types.Level, format string, args []any) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.log(ctx, level, fmt.Sprintf(format, args...), nil)
+}
+
+// DebugFn logs at debug level the message and fields returned by fn. fn is only called
+// when the debug level is enabled, so expensive payloads cost nothing otherwise.
+//
+// Example:
+//
+//	logger.DebugFn(ctx, func() (string, map[string]any) {
+//		return "request body", map[string]any{"body": dump(req)}
+//	})
+func (l *Logger) DebugFn(ctx context.Context, fn func() (string, map[string]any)) {
+	l.logFn(ctx, types.LevelDebug, fn)
+}
+
+// InfoFn logs at info level the message and fields returned by fn, calling fn only
+// when the info level is enabled.
+func (l *Logger) InfoFn(ctx context.Context, fn func() (string, map[string]any)) {
+	l.logFn(ctx, types.LevelInfo, fn)
+}
+
+// WarnFn logs at warning level the message and fields returned by fn, calling fn only
+// when the warning level is enabled.
+func (l *Logger) WarnFn(ctx context.Context, fn func() (string, map[string]any)) {
+	l.logFn(ctx, types.LevelWarn, fn)
+}
+
+// ErrorFn logs at error level the message and fields returned by fn, calling fn only
+// when the error level is enabled.
+func (l *Logger) ErrorFn(ctx context.Context, fn func() (string, map[string]any)) {
+	l.logFn(ctx, types.LevelError, fn)
+}
+
+// logFn calls fn only if the level is enabled.
+func (l *Logger) logFn(ctx context.Context, level types.Level, fn func() (string, map[string]any)) {
+	if !l.Enabled(level) {
+		return
+	}
+	message, fields := fn()
+	l.log(ctx, level, message, fields)
+}
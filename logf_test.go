@@ -0,0 +1,83 @@
+package loki
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerEnabled(t *testing.T) {
+	logger, _ := newTestLoggerWithMock(t)
+	logger.config.LogLevel = types.LevelWarn
+
+	assert.False(t, logger.Enabled(types.LevelDebug))
+	assert.False(t, logger.Enabled(types.LevelInfo))
+	assert.True(t, logger.Enabled(types.LevelWarn))
+	assert.True(t, logger.Enabled(types.LevelFatal))
+}
+
+func TestLoggerPrintf(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	ctx := context.Background()
+
+	logger.Debugf(ctx, "debug %d", 1)
+	logger.Infof(ctx, "info %s", "two")
+	logger.Warnf(ctx, "warn %v", true)
+	logger.Errorf(ctx, "error %q", "four")
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 4)
+	assert.Equal(t, types.LevelDebug, entries[0].Level)
+	assert.Equal(t, "debug 1", entries[0].Message)
+	assert.Equal(t, types.LevelInfo, entries[1].Level)
+	assert.Equal(t, "info two", entries[1].Message)
+	assert.Equal(t, types.LevelWarn, entries[2].Level)
+	assert.Equal(t, "warn true", entries[2].Message)
+	assert.Equal(t, types.LevelError, entries[3].Level)
+	assert.Equal(t, `error "four"`, entries[3].Message)
+	assert.NotEmpty(t, entries[3].Fields["file"])
+
+	// Disabled levels are not formatted
+	logger.config.LogLevel = types.LevelInfo
+	formatted := false
+	logger.Debugf(ctx, "debug %v", stringerFunc(func() string { formatted = true; return "" }))
+	assert.False(t, formatted)
+	assert.Len(t, mock.GetEntries(), 4)
+}
+
+func TestLoggerLazy(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.config.LogLevel = types.LevelInfo
+	ctx := context.Background()
+
+	calls := 0
+	payload := func(message string) func() (string, map[string]any) {
+		return func() (string, map[string]any) {
+			calls++
+			return message, map[string]any{"size": 42}
+		}
+	}
+
+	logger.DebugFn(ctx, payload("debug"))
+	assert.Zero(t, calls, "closure must not run for a disabled level")
+
+	logger.InfoFn(ctx, payload("info"))
+	logger.WarnFn(ctx, payload("warn"))
+	logger.ErrorFn(ctx, payload("error"))
+	assert.Equal(t, 3, calls)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, types.LevelInfo, entries[0].Level)
+	assert.Equal(t, "info", entries[0].Message)
+	assert.Equal(t, 42, entries[0].Fields["size"])
+	assert.Equal(t, types.LevelWarn, entries[1].Level)
+	assert.Equal(t, types.LevelError, entries[2].Level)
+}
+
+type stringerFunc func() string
+
+func (f stringerFunc) String() string { return f() }
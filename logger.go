@@ -4,20 +4,39 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"os"
 	"path/filepath"
-	"runtime/debug"
 	"sync"
 	"time"
 
+	"github.com/edaniel30/loki-logger-go/internal/cardinality"
+	"github.com/edaniel30/loki-logger-go/internal/client"
+	"github.com/edaniel30/loki-logger-go/internal/dedupe"
+	"github.com/edaniel30/loki-logger-go/internal/labelrules"
+	"github.com/edaniel30/loki-logger-go/internal/metrics"
+	"github.com/edaniel30/loki-logger-go/internal/ratelimit"
+	"github.com/edaniel30/loki-logger-go/internal/redact"
 	"github.com/edaniel30/loki-logger-go/internal/transport"
 	"github.com/edaniel30/loki-logger-go/types"
 	"github.com/edaniel30/loki-logger-go/utils"
 )
 
+// systemLabels are the labels set by the logger on every entry. They cannot be
+// overridden and are never dropped by label validation.
+var systemLabels = []string{"app", "level", "version", "environment"}
+
 type Logger struct {
-	config     Config
-	transports []transport.Transport
-	mu         sync.RWMutex
+	config      Config
+	transports  []transport.Transport
+	limiter     *ratelimit.Limiter   // nil when rate limiting is disabled
+	deduper     *dedupe.Deduper      // nil when deduplication is disabled
+	redactor    *redact.Redactor     // nil when no redaction rules are configured
+	metrics     *metrics.Registry    // pipeline counters reported by Stats
+	cardinality *cardinality.Limiter // nil when no fields are promoted to labels
+	labelRules  labelrules.Rules     // Loki label constraints enforced on every entry
+	fields      map[string]any       // default fields added by WithFields
+	metadata    map[string]string    // default structured metadata added by WithMetadata
+	mu          sync.RWMutex
 }
 
 func New(config *Config, opts ...Option) (*Logger, error) {
@@ -32,6 +51,23 @@ func New(config *Config, opts ...Option) (*Logger, error) {
 	logger := &Logger{
 		config:     *config,
 		transports: make([]transport.Transport, 0),
+		redactor:   newRedactor(config.Redaction),
+		metrics:    metrics.New(),
+		labelRules: config.labelRules(),
+	}
+
+	if config.RateLimitLines > 0 || config.RateLimitBytes > 0 {
+		logger.limiter = ratelimit.New(config.RateLimitLines, config.RateLimitBytes)
+	}
+
+	if len(config.PromotedFields) > 0 {
+		logger.cardinality = cardinality.New(config.MaxLabelValues)
+	}
+
+	if config.DedupeWindow > 0 {
+		logger.deduper = dedupe.New(config.DedupeWindow, func(entry *types.Entry) {
+			logger.write(context.Background(), entry)
+		})
 	}
 
 	logger.setupTransports()
@@ -41,25 +77,54 @@ func New(config *Config, opts ...Option) (*Logger, error) {
 
 func (l *Logger) setupTransports() {
 	// always add console transport
-	consoleTransport := transport.NewConsoleTransport()
+	consoleTransport := transport.NewConsoleTransportWithConfig(l.config.consoleTransportConfig())
 	l.transports = append(l.transports, consoleTransport)
 
 	// if not only console, add loki transport
 	if !l.config.OnlyConsole {
+		maxBuffered := 0
+		if l.config.CircuitBreakerPolicy == CircuitBreakerBuffer {
+			maxBuffered = l.config.CircuitBreakerBufferSize
+		}
+
 		lokiTransport := transport.NewLokiTransport(&transport.LokiTransportConfig{
 			LokiURL:       l.config.LokiHost,
 			LokiUsername:  l.config.LokiUsername,
 			LokiPassword:  l.config.LokiPassword,
 			BatchSize:     l.config.BatchSize,
+			MaxBatchBytes: l.config.MaxBatchBytes,
 			FlushInterval: l.config.FlushInterval,
 			MaxRetries:    l.config.MaxRetries,
 			Timeout:       l.config.Timeout,
 			OnFlushError:  l.config.OnFlushError,
+			OnRejected:    l.onRejected(),
+			Metrics:       l.metrics.Transport("loki"),
+			CircuitBreaker: client.BreakerConfig{
+				FailureThreshold: l.config.CircuitBreakerThreshold,
+				OpenTimeout:      l.config.CircuitBreakerTimeout,
+				OnStateChange:    l.onCircuitStateChange(),
+			},
+			MaxBufferedWhileOpen: maxBuffered,
+			MetadataKeys:         l.config.MetadataKeys,
+			MaxLineSize:          l.config.MaxLineSize,
+			LineStrategy:         client.LineStrategy(l.config.LineSizePolicy),
+			StrictTimestamps:     l.config.StrictTimestamps,
 		})
 		l.transports = append(l.transports, lokiTransport)
 	}
 }
 
+// onCircuitStateChange adapts Config.OnCircuitStateChange to the client's breaker states.
+func (l *Logger) onCircuitStateChange() func(from, to client.BreakerState) {
+	fn := l.config.OnCircuitStateChange
+	if fn == nil {
+		return nil
+	}
+	return func(from, to client.BreakerState) {
+		fn(CircuitState(from), CircuitState(to))
+	}
+}
+
 // Debug logs a message at debug level with optional structured fields.
 // Debug logs are typically used for detailed diagnostic information during development.
 func (l *Logger) Debug(ctx context.Context, message string, fields map[string]any) {
@@ -80,27 +145,119 @@ func (l *Logger) Warn(ctx context.Context, message string, fields map[string]any
 
 // Error logs a message at error level with optional structured fields.
 // Error logs indicate error conditions that should be investigated.
-// If IncludeStackTrace is enabled, automatically includes a stack trace.
+// A "stacktrace" field is included when LevelError is in Config.StackTraceLevels (the default).
+// Use ErrorField to attach an error as structured data.
 func (l *Logger) Error(ctx context.Context, message string, fields map[string]any) {
 	l.log(ctx, types.LevelError, message, fields)
 }
 
-// Fatal logs a message at fatal level with optional structured fields.
-// Fatal logs indicate severe errors that may cause application failure.
-// Stack traces are automatically included for fatal logs.
+// Log logs a message at the given level with optional structured fields.
+// It is useful when the level is only known at runtime. Unlike Panic and Fatal,
+// Log never panics or exits, whatever the level.
+func (l *Logger) Log(ctx context.Context, level types.Level, message string, fields map[string]any) {
+	l.log(ctx, level, message, fields)
+}
+
+// LogAt logs a message at the given level with a caller-provided timestamp instead of the
+// current time, for example to import events from batch jobs or replay audit trails with
+// their original time. A zero ts uses the current time. Like Log, it never panics or exits.
+//
+// Loki rejects entries older than its reject_old_samples_max_age or too far in the future.
+// LogAt drops such entries and returns a *TimestampError, based on
+// Config.RejectOldSamplesMaxAge and Config.CreationGracePeriod.
+//
+// Example:
+//
+//	for _, event := range events {
+//		if err := logger.LogAt(ctx, event.Time, types.LevelInfo, event.Action, event.Fields); err != nil {
+//			skipped++
+//		}
+//	}
+func (l *Logger) LogAt(ctx context.Context, ts time.Time, level types.Level, message string, fields map[string]any) error {
+	if !level.IsEnabled(l.config.LogLevel) {
+		return nil
+	}
+
+	if err := l.checkTimestamp(ts); err != nil {
+		l.metrics.OutOfWindow.Inc()
+		return err
+	}
+
+	l.logAt(ctx, ts, level, message, fields, nil)
+	return nil
+}
+
+// checkTimestamp reports whether Loki would reject an entry with the given timestamp.
+func (l *Logger) checkTimestamp(ts time.Time) error {
+	if ts.IsZero() {
+		return nil
+	}
+
+	now := time.Now()
+	if maxAge := l.config.RejectOldSamplesMaxAge; maxAge > 0 && ts.Before(now.Add(-maxAge)) {
+		return &TimestampError{Timestamp: ts, Message: fmt.Sprintf("older than %s", maxAge)}
+	}
+	if grace := l.config.CreationGracePeriod; grace > 0 && ts.After(now.Add(grace)) {
+		return &TimestampError{Timestamp: ts, Message: fmt.Sprintf("more than %s in the future", grace)}
+	}
+
+	return nil
+}
+
+// Panic logs a message at panic level with optional structured fields,
+// flushes all transports and then panics with the message.
+// The panic happens even if LevelPanic is below the configured LogLevel.
+// A "stacktrace" field is included when LevelPanic is in Config.StackTraceLevels (the default).
+func (l *Logger) Panic(ctx context.Context, message string, fields map[string]any) {
+	l.log(ctx, types.LevelPanic, message, fields)
+	l.flushBeforeExit()
+	panic(message)
+}
+
+// Fatal logs a message at fatal level with optional structured fields,
+// flushes all transports and then terminates the process through Config.ExitFunc
+// (os.Exit(1) by default). Deferred functions are not run.
+// A "stacktrace" field is included when LevelFatal is in Config.StackTraceLevels (the default).
 func (l *Logger) Fatal(ctx context.Context, message string, fields map[string]any) {
 	l.log(ctx, types.LevelFatal, message, fields)
+	l.flushBeforeExit()
+	l.exit(1)
+}
+
+// flushBeforeExit synchronously flushes all transports, bounded by Config.Timeout,
+// so the entry that caused the exit or panic is not lost.
+func (l *Logger) flushBeforeExit() {
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.Timeout)
+	defer cancel()
+	_ = l.Flush(ctx) // errors reported via OnFlushError
+}
+
+// exit terminates the process with the configured exit function.
+func (l *Logger) exit(code int) {
+	if l.config.ExitFunc != nil {
+		l.config.ExitFunc(code)
+		return
+	}
+	os.Exit(code)
 }
 
 func (l *Logger) log(ctx context.Context, level types.Level, message string, fields map[string]any) {
+	l.logAt(ctx, time.Time{}, level, message, fields, nil)
+}
+
+// logAt runs an entry through the pipeline. A zero ts uses the current time.
+// extraLabels are added to the logger's labels for this entry only.
+func (l *Logger) logAt(ctx context.Context, ts time.Time, level types.Level, message string, fields map[string]any, extraLabels types.Labels) {
 	if !level.IsEnabled(l.config.LogLevel) {
 		return
 	}
 
-	// Use fields directly (no merge needed since we only accept one map now)
-	if fields == nil {
-		fields = make(map[string]any)
-	}
+	// Work on a copy so the caller's map is never modified by the automatic fields or hooks.
+	// Default fields from WithFields are merged in; caller fields take precedence.
+	merged := make(map[string]any, len(l.fields)+len(fields)+4)
+	maps.Copy(merged, l.fields)
+	maps.Copy(merged, fields)
+	fields = merged
 
 	// Automatically inject trace ID from context if an extractor is configured
 	// and the caller has not already provided it in fields.
@@ -112,6 +269,12 @@ func (l *Logger) log(ctx context.Context, level types.Level, message string, fie
 		}
 	}
 
+	if l.config.TraceContextExtractor != nil {
+		if tc, ok := l.config.TraceContextExtractor(ctx); ok {
+			addTraceFields(fields, tc)
+		}
+	}
+
 	// Automatically add caller information (file and line) if not already present
 	// Uses utils.GetCaller() to dynamically find the first caller outside the logger package
 	if file, line, ok := utils.GetCaller(); ok {
@@ -123,15 +286,23 @@ func (l *Logger) log(ctx context.Context, level types.Level, message string, fie
 		}
 	}
 
-	if level == types.LevelError || level == types.LevelFatal {
-		stack := string(debug.Stack())
-		message = fmt.Sprintf("%s\n\nStack trace:\n%s", message, stack)
+	// Capture a structured stack trace (logger frames trimmed) for the configured levels
+	if l.config.capturesStackTrace(level) {
+		if _, exists := fields["stacktrace"]; !exists {
+			fields["stacktrace"] = utils.GetStackTrace()
+		}
 	}
 
 	labels := make(types.Labels)
 
 	// Copy user-provided labels first
 	maps.Copy(labels, l.config.Labels)
+	maps.Copy(labels, extraLabels)
+
+	// Promote configured fields to labels, within the cardinality limits
+	if l.cardinality != nil {
+		l.promoteFields(ctx, fields, labels)
+	}
 
 	// Set system labels last to prevent user overrides
 	// These are reserved keys that ensure consistent Loki indexing
@@ -140,14 +311,68 @@ func (l *Logger) log(ctx context.Context, level types.Level, message string, fie
 	labels["version"] = l.config.AppVersion
 	labels["environment"] = l.config.AppEnv
 
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
 	transportEntry := &types.Entry{
 		Level:     level,
 		Message:   message,
 		Fields:    fields,
-		Timestamp: time.Now(),
+		Timestamp: ts,
 		Labels:    labels,
 	}
 
+	if len(l.metadata) > 0 {
+		transportEntry.Metadata = maps.Clone(l.metadata)
+	}
+
+	// Let user hooks enrich, rewrite or drop the entry
+	if !l.runHooks(ctx, transportEntry) {
+		l.metrics.HookDropped.Inc()
+		return
+	}
+
+	// Enforce Loki's label rules last so labels added by hooks are covered too;
+	// a single invalid label would make Loki reject the whole batch
+	if transportEntry.Labels != nil {
+		l.labelRules.Apply(transportEntry.Labels, systemLabels...)
+	}
+
+	// Scrub sensitive data before the entry can reach any transport
+	if l.redactor != nil {
+		transportEntry.Message = l.redactor.Message(transportEntry.Message)
+		transportEntry.Fields = l.redactor.Fields(transportEntry.Fields)
+		transportEntry.Metadata = l.redactor.Strings(transportEntry.Metadata)
+	}
+
+	// Collapse repeated entries; the summary is written later by the deduper
+	if l.deduper != nil && !l.deduper.Add(transportEntry) {
+		l.metrics.Deduplicated.Inc()
+		return
+	}
+
+	l.metrics.Logged(level)
+
+	l.write(ctx, transportEntry)
+}
+
+// write applies the per-stream rate limit and sends the entry to every transport.
+func (l *Logger) write(ctx context.Context, entry *types.Entry) {
+	// Enforce the per-stream rate limit. Downgraded entries still reach the console
+	// so they are visible locally, but are kept away from Loki.
+	consoleOnly := false
+	if l.limiter != nil && !l.limiter.Allow(entry.Labels.Key(), ratelimit.EntrySize(entry)) {
+		l.metrics.RateLimited.Inc()
+		if l.config.OnRateLimited != nil {
+			l.config.OnRateLimited(entry, l.config.RateLimitAction)
+		}
+		if l.config.RateLimitAction != RateLimitDowngrade {
+			return
+		}
+		consoleOnly = true
+	}
+
 	// Use provided context with a timeout if it doesn't already have a deadline
 	writeCtx := ctx
 	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
@@ -160,27 +385,52 @@ func (l *Logger) log(ctx context.Context, level types.Level, message string, fie
 	defer l.mu.RUnlock()
 
 	for _, t := range l.transports {
-		// Write to transport, errors are logged but don't stop execution
-		_ = t.Write(writeCtx, transportEntry)
+		if consoleOnly && t.Name() != "console" {
+			continue
+		}
+		// Write to transport, errors are counted but don't stop execution
+		m := l.metrics.Transport(t.Name())
+		m.Written.Inc()
+		if err := t.Write(writeCtx, entry); err != nil {
+			m.WriteErrors.Inc()
+		}
 	}
 }
 
-// Close releases all resources held by the logger.
-// After calling Close, the logger should not be used.
-// Flushes buffered logs before closing transports.
-func (l *Logger) Close() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// Flush writes pending deduplication summaries and sends all buffered entries
+// to their destination. It returns the first transport flush error, if any.
+func (l *Logger) Flush(ctx context.Context) error {
+	if l.deduper != nil {
+		l.deduper.Flush()
+	}
 
 	l.mu.RLock()
-	// Flush all transports first
+	defer l.mu.RUnlock()
+
 	var flushErr error
 	for _, t := range l.transports {
 		if err := t.Flush(ctx); err != nil && flushErr == nil {
 			flushErr = err
 		}
 	}
-	l.mu.RUnlock()
+
+	return flushErr
+}
+
+// Close releases all resources held by the logger.
+// After calling Close, the logger should not be used.
+// Flushes buffered logs before closing transports.
+func (l *Logger) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Stop the deduper first so its final summaries reach the transports
+	if l.deduper != nil {
+		l.deduper.Close()
+	}
+
+	// Flush all transports first
+	flushErr := l.Flush(ctx)
 
 	// Now close all transports
 	l.mu.Lock()
@@ -204,21 +454,84 @@ func (l *Logger) Close() error {
 // This is useful for adding context to all logs from a specific component.
 // Labels are indexed by Loki and should have low cardinality (< 50 unique values per label).
 func (l *Logger) WithLabels(labels types.Labels) *Logger {
-	// Deep copy the config to avoid modifying the original logger
-	newConfig := l.config
+	newLogger := l.clone()
 
-	// Deep copy the Labels map
-	newConfig.Labels = make(types.Labels)
-	maps.Copy(newConfig.Labels, l.config.Labels)
+	// Deep copy the Labels map to avoid modifying the original logger
+	newLogger.config.Labels = make(types.Labels)
+	maps.Copy(newLogger.config.Labels, l.config.Labels)
 
 	// Add new labels (already string type)
-	maps.Copy(newConfig.Labels, labels)
+	maps.Copy(newLogger.config.Labels, labels)
 
-	// Share transports with parent logger (they are thread-safe and designed to be shared)
-	newLogger := &Logger{
-		config:     newConfig,
-		transports: l.transports, // Shared (thread-safe)
+	return newLogger
+}
+
+// WithCheckedLabels is like WithLabels but validates the resulting label set against
+// Loki's naming rules and the configured limits, returning an error instead of
+// sanitizing or dropping invalid labels, whatever the LabelPolicy.
+//
+// Example:
+//
+//	dbLogger, err := logger.WithCheckedLabels(types.Labels{"component": "db"})
+//	if err != nil {
+//		return err
+//	}
+func (l *Logger) WithCheckedLabels(labels types.Labels) (*Logger, error) {
+	newLogger := l.WithLabels(labels)
+
+	if err := l.labelRules.CheckAll(newLogger.config.Labels, systemLabels...); err != nil {
+		return nil, newLabelError(err)
 	}
 
+	return newLogger, nil
+}
+
+// WithFields creates a new logger that adds the given fields to every entry.
+// Fields passed to a log call take precedence over these defaults.
+// Unlike labels, fields are not indexed, so high-cardinality values such as
+// request IDs or user IDs belong here.
+func (l *Logger) WithFields(fields map[string]any) *Logger {
+	newLogger := l.clone()
+
+	newLogger.fields = make(map[string]any, len(l.fields)+len(fields))
+	maps.Copy(newLogger.fields, l.fields)
+	maps.Copy(newLogger.fields, fields)
+
 	return newLogger
 }
+
+// WithMetadata creates a new logger that attaches the given structured metadata to every entry.
+// Structured metadata is sent to Loki alongside each line without being indexed or added
+// to the JSON body, which suits high-cardinality values such as request or user IDs.
+// Requires Loki 3.x. See also Config.MetadataKeys.
+//
+// Example:
+//
+//	reqLogger := logger.WithMetadata(map[string]string{"user_id": userID})
+func (l *Logger) WithMetadata(metadata map[string]string) *Logger {
+	newLogger := l.clone()
+
+	newLogger.metadata = make(map[string]string, len(l.metadata)+len(metadata))
+	maps.Copy(newLogger.metadata, l.metadata)
+	maps.Copy(newLogger.metadata, metadata)
+
+	return newLogger
+}
+
+// clone returns a child logger sharing the parent's pipeline state.
+// The config is copied by value; callers must deep copy any map they modify.
+func (l *Logger) clone() *Logger {
+	// Share transports with parent logger (they are thread-safe and designed to be shared)
+	return &Logger{
+		config:      l.config,
+		transports:  l.transports,  // Shared (thread-safe)
+		limiter:     l.limiter,     // Shared so limits apply across child loggers
+		deduper:     l.deduper,     // Shared so duplicates collapse across child loggers
+		redactor:    l.redactor,    // Immutable, safe to share
+		metrics:     l.metrics,     // Shared so Stats covers child loggers
+		cardinality: l.cardinality, // Shared so limits apply across child loggers
+		labelRules:  l.labelRules,  // Immutable, safe to share
+		fields:      l.fields,      // Never modified in place, safe to share
+		metadata:    l.metadata,    // Never modified in place, safe to share
+	}
+}
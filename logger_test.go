@@ -2,15 +2,21 @@ package loki
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/edaniel30/loki-logger-go/internal/mocks"
 	"github.com/edaniel30/loki-logger-go/internal/transport"
 	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/edaniel30/loki-logger-go/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -26,6 +32,7 @@ func newTestConfig() *Config {
 		MaxRetries:        3,
 		Timeout:           10 * time.Second,
 		Labels:            make(types.Labels),
+		ExitFunc:          func(int) {}, // never terminate the test binary
 	}
 }
 
@@ -100,15 +107,17 @@ func TestLoggerLogLevels(t *testing.T) {
 	logger.Info(ctx, "info", nil)
 	logger.Warn(ctx, "warn", nil)
 	logger.Error(ctx, "error", nil)
+	assert.Panics(t, func() { logger.Panic(ctx, "panic", nil) })
 	logger.Fatal(ctx, "fatal", nil)
 
 	entries := mock.GetEntries()
-	require.Len(t, entries, 5)
+	require.Len(t, entries, 6)
 	assert.Equal(t, types.LevelDebug, entries[0].Level)
 	assert.Equal(t, types.LevelInfo, entries[1].Level)
 	assert.Equal(t, types.LevelWarn, entries[2].Level)
 	assert.Equal(t, types.LevelError, entries[3].Level)
-	assert.Equal(t, types.LevelFatal, entries[4].Level)
+	assert.Equal(t, types.LevelPanic, entries[4].Level)
+	assert.Equal(t, types.LevelFatal, entries[5].Level)
 }
 
 func TestLoggerLabels(t *testing.T) {
@@ -190,7 +199,9 @@ func TestLoggerFields(t *testing.T) {
 }
 
 func TestLoggerStackTrace(t *testing.T) {
+	// A config not built with DefaultConfig keeps the default levels
 	cfg := newTestConfig()
+	require.Nil(t, cfg.StackTraceLevels)
 	logger, _ := New(cfg)
 	mock := mocks.NewMockTransport("mock")
 	logger.transports = []transport.Transport{mock}
@@ -198,28 +209,64 @@ func TestLoggerStackTrace(t *testing.T) {
 	logger.Error(context.Background(), "error message", nil)
 	entries := mock.GetEntries()
 	require.Len(t, entries, 1)
-	assert.Contains(t, entries[0].Message, "Stack trace:")
-	assert.Contains(t, entries[0].Message, "error message")
+	// The stack is a structured field; the message is left untouched
+	assert.Equal(t, "error message", entries[0].Message)
+	require.Contains(t, entries[0].Fields, "stacktrace")
+	stack, ok := entries[0].Fields["stacktrace"].([]utils.Frame)
+	require.True(t, ok)
+	require.NotEmpty(t, stack)
+	// Logger frames are trimmed: tests live in the logger package, so the
+	// first frame outside of it is the test runner
+	assert.Equal(t, "testing.tRunner", stack[0].Function)
+	for _, frame := range stack {
+		assert.NotContains(t, frame.Function, utils.LoggerPackageName)
+		assert.NotEmpty(t, frame.File)
+		assert.Greater(t, frame.Line, 0)
+	}
 
 	mock.Reset()
 	logger.Fatal(context.Background(), "fatal error", nil)
 	entries = mock.GetEntries()
 	require.Len(t, entries, 1)
-	assert.Contains(t, entries[0].Message, "Stack trace:")
+	assert.Contains(t, entries[0].Fields, "stacktrace")
 
 	// Info and Debug should NOT have stack traces
 	mock.Reset()
 	logger.Info(context.Background(), "info message", nil)
 	entries = mock.GetEntries()
 	require.Len(t, entries, 1)
-	assert.NotContains(t, entries[0].Message, "Stack trace:")
+	assert.NotContains(t, entries[0].Fields, "stacktrace")
 	assert.Equal(t, "info message", entries[0].Message)
 
 	mock.Reset()
 	logger.Debug(context.Background(), "debug message", nil)
 	entries = mock.GetEntries()
 	require.Len(t, entries, 1)
-	assert.NotContains(t, entries[0].Message, "Stack trace:")
+	assert.NotContains(t, entries[0].Fields, "stacktrace")
+
+	// Stack traces are configurable per level
+	cfg = newTestConfig()
+	cfg.StackTraceLevels = []types.Level{types.LevelWarn}
+	logger, _ = New(cfg)
+	logger.transports = []transport.Transport{mock}
+
+	mock.Reset()
+	logger.Warn(context.Background(), "warn message", nil)
+	logger.Error(context.Background(), "error message", nil)
+	entries = mock.GetEntries()
+	require.Len(t, entries, 2)
+	assert.Contains(t, entries[0].Fields, "stacktrace")
+	assert.NotContains(t, entries[1].Fields, "stacktrace")
+
+	// An empty list disables stack traces
+	logger, _ = New(newTestConfig(), WithStackTraceLevels())
+	logger.transports = []transport.Transport{mock}
+
+	mock.Reset()
+	logger.Error(context.Background(), "error message", nil)
+	entries = mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.NotContains(t, entries[0].Fields, "stacktrace")
 }
 
 func TestLoggerWithOnFlushError(t *testing.T) {
@@ -333,3 +380,505 @@ func TestLoggerWithLabels(t *testing.T) {
 	require.Len(t, entries, 1)
 	assert.Equal(t, "prod", entries[0].Labels["env"])
 }
+
+func TestLoggerRateLimit(t *testing.T) {
+	t.Run("drop", func(t *testing.T) {
+		var limited []RateLimitAction
+		cfg := newTestConfig()
+		cfg.RateLimitLines = 2
+		cfg.OnRateLimited = func(entry *types.Entry, action RateLimitAction) {
+			limited = append(limited, action)
+		}
+		logger, err := New(cfg)
+		require.NoError(t, err)
+		mock := mocks.NewMockTransport("mock")
+		logger.transports = []transport.Transport{mock}
+
+		for range 5 {
+			logger.Info(context.Background(), "noisy", nil)
+		}
+
+		assert.Len(t, mock.GetEntries(), 2)
+		assert.Equal(t, []RateLimitAction{RateLimitDrop, RateLimitDrop, RateLimitDrop}, limited)
+
+		// A different stream (label set) has its own budget, also across child loggers
+		child := logger.WithLabels(types.Labels{"component": "db"})
+		child.Info(context.Background(), "quiet", nil)
+		assert.Len(t, mock.GetEntries(), 3)
+	})
+
+	t.Run("downgrade keeps console only", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.RateLimitLines = 1
+		cfg.RateLimitAction = RateLimitDowngrade
+		logger, err := New(cfg)
+		require.NoError(t, err)
+		console := mocks.NewMockTransport("console")
+		lokiMock := mocks.NewMockTransport("loki")
+		logger.transports = []transport.Transport{console, lokiMock}
+
+		logger.Warn(context.Background(), "first", nil)
+		logger.Warn(context.Background(), "second", nil)
+
+		assert.Len(t, console.GetEntries(), 2)
+		require.Len(t, lokiMock.GetEntries(), 1)
+		assert.Equal(t, "first", lokiMock.GetEntries()[0].Message)
+	})
+}
+
+func TestLoggerDedupe(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.DedupeWindow = time.Hour
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	mock := mocks.NewMockTransport("mock")
+	logger.transports = []transport.Transport{mock}
+
+	ctx := context.Background()
+	for range 4 {
+		logger.Warn(ctx, "dependency down", map[string]any{"dependency": "db"})
+	}
+	logger.Warn(ctx, "something else", nil)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "dependency down", entries[0].Message)
+	assert.NotContains(t, entries[0].Fields, "repeat_count")
+
+	require.NoError(t, logger.Flush(ctx))
+	entries = mock.GetEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "dependency down", entries[2].Message)
+	assert.Equal(t, 3, entries[2].Fields["repeat_count"])
+	assert.Contains(t, entries[2].Fields, "first_timestamp")
+	assert.Contains(t, entries[2].Fields, "last_timestamp")
+	assert.Equal(t, 1, mock.FlushCalled)
+
+	// Close emits summaries still pending
+	logger.Warn(ctx, "dependency down", nil)
+	logger.Warn(ctx, "dependency down", nil)
+	require.NoError(t, logger.Close())
+	entries = mock.GetEntries()
+	require.Len(t, entries, 5)
+	assert.Equal(t, 1, entries[4].Fields["repeat_count"])
+}
+
+func TestLoggerFlush(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	require.NoError(t, logger.Flush(context.Background()))
+	assert.Equal(t, 1, mock.FlushCalled)
+
+	mock.FlushErr = errors.New("flush failed")
+	assert.EqualError(t, logger.Flush(context.Background()), "flush failed")
+}
+
+func TestLoggerFatalExit(t *testing.T) {
+	var events []string
+	cfg := newTestConfig()
+	cfg.ExitFunc = func(code int) {
+		events = append(events, "exit")
+		assert.Equal(t, 1, code)
+	}
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	mock := mocks.NewMockTransport("mock")
+	logger.transports = []transport.Transport{mock}
+	logger.config.Hooks = []Hook{HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+		events = append(events, "log")
+		return true
+	})}
+
+	logger.Fatal(context.Background(), "cannot continue", nil)
+
+	// The entry is logged and flushed before exiting
+	assert.Equal(t, []string{"log", "exit"}, events)
+	assert.Len(t, mock.GetEntries(), 1)
+	assert.Equal(t, 1, mock.FlushCalled)
+
+	// Child loggers inherit the exit function
+	events = nil
+	logger.WithLabels(types.Labels{"component": "db"}).Fatal(context.Background(), "db gone", nil)
+	assert.Equal(t, []string{"log", "exit"}, events)
+}
+
+func TestLoggerPanic(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.LogLevel = types.LevelFatal // panics even when the level is disabled
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	mock := mocks.NewMockTransport("mock")
+	logger.transports = []transport.Transport{mock}
+
+	assert.PanicsWithValue(t, "invariant broken", func() {
+		logger.Panic(context.Background(), "invariant broken", nil)
+	})
+	assert.Empty(t, mock.GetEntries())
+	assert.Equal(t, 1, mock.FlushCalled)
+
+	logger.config.LogLevel = types.LevelDebug
+	assert.Panics(t, func() {
+		logger.Panic(context.Background(), "invariant broken", nil)
+	})
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, types.LevelPanic, entries[0].Level)
+	assert.Equal(t, "panic", entries[0].Labels["level"])
+	assert.Equal(t, 2, mock.FlushCalled)
+}
+
+func TestLoggerWithFields(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+
+	child := logger.WithFields(map[string]any{"request_id": "abc", "user": "default"})
+	grandchild := child.WithLabels(types.Labels{"component": "api"}).WithFields(map[string]any{"step": 2})
+
+	child.Info(context.Background(), "test", map[string]any{"user": "bob"})
+	grandchild.Info(context.Background(), "test", nil)
+	logger.Info(context.Background(), "test", nil)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "abc", entries[0].Fields["request_id"])
+	assert.Equal(t, "bob", entries[0].Fields["user"]) // caller fields take precedence
+
+	assert.Equal(t, "abc", entries[1].Fields["request_id"])
+	assert.Equal(t, 2, entries[1].Fields["step"])
+	assert.Equal(t, "api", entries[1].Labels["component"])
+
+	assert.NotContains(t, entries[2].Fields, "request_id")
+	assert.NotContains(t, child.fields, "step")
+}
+
+func TestLoggerDoesNotModifyCallerFields(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.TraceIDExtractor = func(ctx context.Context) string { return "trace-1" }
+	cfg.Hooks = []Hook{HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+		entry.Fields["hooked"] = true
+		return true
+	})}
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	mock := mocks.NewMockTransport("mock")
+	logger.transports = []transport.Transport{mock}
+
+	fields := map[string]any{"order_id": 42}
+	logger.Error(context.Background(), "test", fields)
+
+	// The entry carries the automatic fields, the caller's map is left as it was
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "trace-1", entries[0].Fields["trace_id"])
+	assert.Contains(t, entries[0].Fields, "file")
+	assert.Contains(t, entries[0].Fields, "stacktrace")
+	assert.Equal(t, true, entries[0].Fields["hooked"])
+	assert.Equal(t, map[string]any{"order_id": 42}, fields)
+}
+
+func TestLoggerCircuitStateChangeCanLog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	// The callback logs through the logger whose push triggered the transition
+	var logger *Logger
+	var transitions []CircuitState
+	cfg := DefaultConfig()
+	cfg.MaxRetries = 0
+	logger, err := New(cfg,
+		WithLokiHost(srv.URL),
+		WithBatchSize(1),
+		WithStrictTimestamps(true),
+		WithConsoleWriter(io.Discard, io.Discard),
+		WithCircuitBreaker(1, time.Minute),
+		WithOnCircuitStateChange(func(from, to CircuitState) {
+			transitions = append(transitions, to)
+			logger.Warn(context.Background(), "loki circuit "+to.String(), nil)
+		}),
+	)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Info(context.Background(), "test", nil)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging from the state change callback deadlocked")
+	}
+	assert.Equal(t, []CircuitState{CircuitOpen}, transitions)
+	_ = logger.Close()
+}
+
+func TestLoggerWithMetadata(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.redactor = newRedactor([]RedactionRule{{Keys: []string{"session"}}})
+
+	child := logger.WithMetadata(map[string]string{"tenant": "acme", "session": "s3cr3t"})
+	grandchild := child.WithFields(map[string]any{"step": 1}).WithMetadata(map[string]string{"tenant": "globex", "user_id": "42"})
+
+	child.Info(context.Background(), "test", nil)
+	grandchild.Info(context.Background(), "test", nil)
+	logger.Info(context.Background(), "test", nil)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, map[string]string{"tenant": "acme", "session": "[REDACTED]"}, entries[0].Metadata)
+	assert.Equal(t, "globex", entries[1].Metadata["tenant"])
+	assert.Equal(t, "42", entries[1].Metadata["user_id"])
+	assert.Equal(t, 1, entries[1].Fields["step"])
+	assert.Nil(t, entries[2].Metadata)
+	assert.NotContains(t, child.metadata, "user_id")
+}
+
+func TestLoggerStructuredMetadata(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	logger, err := New(
+		DefaultConfig(),
+		WithLokiHost(srv.URL),
+		WithBatchSize(1),
+		WithFlushInterval(time.Hour),
+		WithStackTraceLevels(),
+		WithMetadataKeys("trace_id"),
+	)
+	require.NoError(t, err)
+	defer func() { _ = logger.Close() }()
+
+	_ = captureStdout(t, func() {
+		logger.WithMetadata(map[string]string{"user_id": "42"}).
+			Info(context.Background(), "order created", map[string]any{"trace_id": "abc123", "order": "o-1"})
+	})
+
+	var body []byte
+	select {
+	case body = <-bodies:
+	case <-time.After(time.Second):
+		t.Fatal("expected a push to Loki")
+	}
+
+	var payload struct {
+		Streams []struct {
+			Values [][]any `json:"values"`
+		} `json:"streams"`
+	}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Len(t, payload.Streams, 1)
+	require.Len(t, payload.Streams[0].Values, 1)
+
+	value := payload.Streams[0].Values[0]
+	require.Len(t, value, 3)
+	assert.Equal(t, map[string]any{"trace_id": "abc123", "user_id": "42"}, value[2])
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(value[1].(string)), &line))
+	assert.Equal(t, "o-1", line["order"])
+	assert.NotContains(t, line, "trace_id")
+}
+
+func TestLoggerMaxLineSize(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	logger, err := New(
+		DefaultConfig(),
+		WithLokiHost(srv.URL),
+		WithBatchSize(1),
+		WithFlushInterval(time.Hour),
+		WithStackTraceLevels(),
+		WithMaxLineSize(512, LineSplit),
+	)
+	require.NoError(t, err)
+	defer func() { _ = logger.Close() }()
+
+	message := strings.Repeat("0123456789", 200)
+	_ = captureStdout(t, func() {
+		logger.Info(context.Background(), message, map[string]any{"job": "import"})
+	})
+
+	var body []byte
+	select {
+	case body = <-bodies:
+	case <-time.After(time.Second):
+		t.Fatal("expected a push to Loki")
+	}
+
+	var payload struct {
+		Streams []struct {
+			Values [][]string `json:"values"`
+		} `json:"streams"`
+	}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Len(t, payload.Streams, 1)
+	require.Greater(t, len(payload.Streams[0].Values), 4)
+
+	var rebuilt strings.Builder
+	for _, value := range payload.Streams[0].Values {
+		assert.LessOrEqual(t, len(value[1]), 512)
+
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(value[1]), &line))
+		assert.NotEmpty(t, line["split_id"])
+		rebuilt.WriteString(line["message"].(string))
+	}
+	assert.Equal(t, message, rebuilt.String())
+}
+
+func TestLoggerConcurrentTimestamps(t *testing.T) {
+	var mu sync.Mutex
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	logger, err := New(
+		DefaultConfig(),
+		WithLokiHost(srv.URL),
+		WithBatchSize(25),
+		WithFlushInterval(time.Hour),
+		WithStackTraceLevels(),
+		WithStrictTimestamps(true),
+	)
+	require.NoError(t, err)
+
+	_ = captureStdout(t, func() {
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for range 50 {
+					logger.Info(context.Background(), "tick", nil)
+				}
+			})
+		}
+		wg.Wait()
+		require.NoError(t, logger.Close())
+	})
+
+	var timestamps []int64
+	for _, body := range bodies {
+		var payload struct {
+			Streams []struct {
+				Values [][]string `json:"values"`
+			} `json:"streams"`
+		}
+		require.NoError(t, json.Unmarshal(body, &payload))
+		require.Len(t, payload.Streams, 1)
+		for _, value := range payload.Streams[0].Values {
+			ns, err := strconv.ParseInt(value[0], 10, 64)
+			require.NoError(t, err)
+			timestamps = append(timestamps, ns)
+		}
+	}
+
+	require.Len(t, timestamps, 8*50)
+	for i := 1; i < len(timestamps); i++ {
+		require.Greater(t, timestamps[i], timestamps[i-1])
+	}
+}
+
+func TestLoggerLog(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.config.ExitFunc = func(int) { t.Fatal("Log must not exit") }
+	logger.config.LogLevel = types.LevelInfo
+
+	logger.Log(context.Background(), types.LevelWarn, "warn", nil)
+	logger.Log(context.Background(), types.LevelDebug, "filtered", nil)
+	assert.NotPanics(t, func() {
+		logger.Log(context.Background(), types.LevelPanic, "panic", nil)
+		logger.Log(context.Background(), types.LevelFatal, "fatal", nil)
+	})
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, types.LevelWarn, entries[0].Level)
+	assert.Equal(t, types.LevelPanic, entries[1].Level)
+	assert.Equal(t, types.LevelFatal, entries[2].Level)
+}
+
+func TestLoggerLogAt(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.config.LogLevel = types.LevelInfo
+	logger.config.RejectOldSamplesMaxAge = 24 * time.Hour
+	logger.config.CreationGracePeriod = 10 * time.Minute
+	ctx := context.Background()
+
+	eventTime := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	require.NoError(t, logger.LogAt(ctx, eventTime, types.LevelWarn, "imported", map[string]any{"job": "replay"}))
+
+	// A zero timestamp uses the current time
+	before := time.Now()
+	require.NoError(t, logger.LogAt(ctx, time.Time{}, types.LevelInfo, "now", nil))
+
+	// Disabled levels are ignored without validation
+	require.NoError(t, logger.LogAt(ctx, time.Unix(0, 0), types.LevelDebug, "filtered", nil))
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, eventTime, entries[0].Timestamp)
+	assert.Equal(t, "replay", entries[0].Fields["job"])
+	assert.Equal(t, "warn", entries[0].Labels["level"])
+	assert.False(t, entries[1].Timestamp.Before(before))
+
+	// Entries outside Loki's window are dropped
+	var tsErr *TimestampError
+	err := logger.LogAt(ctx, time.Now().Add(-48*time.Hour), types.LevelInfo, "too old", nil)
+	require.ErrorAs(t, err, &tsErr)
+	assert.Contains(t, tsErr.Message, "older than 24h0m0s")
+
+	err = logger.LogAt(ctx, time.Now().Add(time.Hour), types.LevelInfo, "too new", nil)
+	require.ErrorAs(t, err, &tsErr)
+	assert.Contains(t, tsErr.Message, "in the future")
+
+	assert.Len(t, mock.GetEntries(), 2)
+	assert.Equal(t, uint64(2), logger.Stats().OutOfWindow)
+
+	// A zero window accepts any timestamp
+	logger.config.RejectOldSamplesMaxAge = 0
+	require.NoError(t, logger.LogAt(ctx, time.Unix(0, 0), types.LevelInfo, "ancient", nil))
+	assert.Len(t, mock.GetEntries(), 3)
+}
+
+func TestLoggerConsoleOutput(t *testing.T) {
+	var out, errOut strings.Builder
+	cfg := newTestConfig()
+	logger, err := New(cfg,
+		WithConsoleFormat(ConsoleJSON),
+		WithConsoleWriter(&out, &errOut),
+		WithConsoleTime("", time.UTC),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	logger.Info(ctx, "started", map[string]any{"port": 8080})
+	logger.Warn(ctx, "slow", nil)
+
+	var data map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &data))
+	assert.Equal(t, "started", data["message"])
+	assert.Equal(t, float64(8080), data["port"])
+	assert.True(t, strings.HasSuffix(data["timestamp"].(string), "Z"))
+	assert.Equal(t, "test-app", data["labels"].(map[string]any)["app"])
+
+	require.NoError(t, json.Unmarshal([]byte(errOut.String()), &data))
+	assert.Equal(t, "slow", data["message"])
+	assert.NotContains(t, out.String(), "slow")
+}
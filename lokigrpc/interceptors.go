@@ -0,0 +1,315 @@
+// Package lokigrpc provides gRPC server and client interceptors that log calls through a
+// loki.Logger, attach a request-scoped child logger to the context and recover panics.
+//
+// It lives in its own module so the core logger stays free of gRPC dependencies.
+package lokigrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	loki "github.com/edaniel30/loki-logger-go"
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/edaniel30/loki-logger-go/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// requestIDMetadataKey is the metadata key an incoming request ID is read from.
+	requestIDMetadataKey = "x-request-id"
+
+	// traceParentMetadataKey is the metadata key carrying the W3C traceparent.
+	traceParentMetadataKey = "traceparent"
+
+	// maxRequestIDLength is the maximum length of an incoming request ID.
+	maxRequestIDLength = 128
+)
+
+// Config configures the interceptors.
+// Use functional options with the interceptor constructors to customize it.
+type Config struct {
+	// LevelFunc maps the call's status code to a log level (default: CodeToLevel).
+	LevelFunc func(code codes.Code) types.Level
+
+	// Recovery enables recovering panics in server handlers. The call fails with codes.Internal
+	// and its log entry carries the panic, a structured stack trace and the file and line
+	// of the panic site (default: true).
+	Recovery bool
+
+	// Message is the log message for every call (default: "grpc call").
+	Message string
+}
+
+// Option is a function that modifies a Config.
+type Option func(*Config)
+
+// WithLevelFunc sets the function mapping status codes to log levels.
+func WithLevelFunc(fn func(code codes.Code) types.Level) Option {
+	return func(c *Config) {
+		c.LevelFunc = fn
+	}
+}
+
+// WithRecovery enables or disables panic recovery in server interceptors.
+func WithRecovery(enabled bool) Option {
+	return func(c *Config) {
+		c.Recovery = enabled
+	}
+}
+
+// WithMessage sets the log message for every call.
+func WithMessage(message string) Option {
+	return func(c *Config) {
+		c.Message = message
+	}
+}
+
+// newConfig applies the options over the defaults.
+func newConfig(opts []Option) *Config {
+	cfg := &Config{
+		LevelFunc: CodeToLevel,
+		Recovery:  true,
+		Message:   "grpc call",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// CodeToLevel is the default mapping from gRPC status codes to log levels:
+// OK is Info, client-side problems are Warn and server-side failures are Error.
+func CodeToLevel(code codes.Code) types.Level {
+	switch code {
+	case codes.OK:
+		return types.LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return types.LevelWarn
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal,
+		codes.Unavailable, codes.DataLoss:
+		return types.LevelError
+	default:
+		return types.LevelError
+	}
+}
+
+// UnaryServerInterceptor returns a server interceptor that logs every unary call and
+// stores a request-scoped child logger in the handler context (see loki.FromContext).
+func UnaryServerInterceptor(logger *loki.Logger, opts ...Option) grpc.UnaryServerInterceptor {
+	cfg := newConfig(opts)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+		ctx, reqLogger := scopeServerContext(ctx, logger, info.FullMethod)
+
+		if cfg.Recovery {
+			defer func() {
+				if r := recover(); r != nil {
+					err = status.Error(codes.Internal, "internal error")
+					logCall(ctx, reqLogger, cfg, "unary", info.FullMethod, start, err, panicFields(r))
+				}
+			}()
+		}
+
+		resp, err = handler(ctx, req)
+		logCall(ctx, reqLogger, cfg, "unary", info.FullMethod, start, err, nil)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor that logs every streaming call and
+// stores a request-scoped child logger in the stream context (see loki.FromContext).
+func StreamServerInterceptor(logger *loki.Logger, opts ...Option) grpc.StreamServerInterceptor {
+	cfg := newConfig(opts)
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		ctx, reqLogger := scopeServerContext(ss.Context(), logger, info.FullMethod)
+
+		if cfg.Recovery {
+			defer func() {
+				if r := recover(); r != nil {
+					err = status.Error(codes.Internal, "internal error")
+					logCall(ctx, reqLogger, cfg, streamType(info.IsClientStream, info.IsServerStream), info.FullMethod, start, err, panicFields(r))
+				}
+			}()
+		}
+
+		err = handler(srv, &scopedServerStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, reqLogger, cfg, streamType(info.IsClientStream, info.IsServerStream), info.FullMethod, start, err, nil)
+		return err
+	}
+}
+
+// UnaryClientInterceptor returns a client interceptor that logs every outgoing unary call.
+func UnaryClientInterceptor(logger *loki.Logger, opts ...Option) grpc.UnaryClientInterceptor {
+	cfg := newConfig(opts)
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		logCall(ctx, clientLogger(logger, cc), cfg, "unary", method, start, err, nil)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a client interceptor that logs the establishment of
+// every outgoing stream. The duration covers stream creation, not the whole stream.
+func StreamClientInterceptor(logger *loki.Logger, opts ...Option) grpc.StreamClientInterceptor {
+	cfg := newConfig(opts)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, callOpts...)
+		logCall(ctx, clientLogger(logger, cc), cfg, streamType(desc.ClientStreams, desc.ServerStreams), method, start, err, nil)
+		return stream, err
+	}
+}
+
+// scopeServerContext builds the request-scoped child logger and stores it in the context,
+// along with the trace context from the incoming "traceparent" metadata. The request ID is
+// taken from the "x-request-id" metadata when it is valid, and generated otherwise.
+func scopeServerContext(ctx context.Context, logger *loki.Logger, fullMethod string) (context.Context, *loki.Logger) {
+	service, method := splitMethod(fullMethod)
+	fields := map[string]any{
+		"grpc_service": service,
+		"grpc_method":  method,
+		"request_id":   newRequestID(),
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields["peer"] = p.Addr.String()
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestIDMetadataKey); len(ids) > 0 && validRequestID(ids[0]) {
+			fields["request_id"] = ids[0]
+		}
+		if tp := md.Get(traceParentMetadataKey); len(tp) > 0 {
+			if tc, ok := loki.ParseTraceParent(tp[0]); ok {
+				fields["trace_id"] = tc.TraceID
+				ctx = loki.ContextWithTraceContext(ctx, tc)
+			}
+		}
+	}
+
+	reqLogger := logger.WithFields(fields)
+	return loki.NewContext(ctx, reqLogger), reqLogger
+}
+
+// validRequestID reports whether an incoming request ID can be used as is: non-empty, at most
+// maxRequestIDLength bytes and only printable ASCII, as in the httplog middleware.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random 128-bit request ID encoded as hex.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read never returns an error
+	return hex.EncodeToString(b[:])
+}
+
+// clientLogger returns a child logger describing the call target.
+func clientLogger(logger *loki.Logger, cc *grpc.ClientConn) *loki.Logger {
+	if cc == nil {
+		return logger
+	}
+	return logger.WithFields(map[string]any{"peer": cc.Target()})
+}
+
+// logCall writes the log entry describing a finished call, with extra fields if any.
+func logCall(ctx context.Context, logger *loki.Logger, cfg *Config, kind, fullMethod string, start time.Time, err error, extra map[string]any) {
+	code := status.Code(err)
+	service, method := splitMethod(fullMethod)
+
+	fields := maps.Clone(extra)
+	if fields == nil {
+		fields = make(map[string]any, 6)
+	}
+	maps.Copy(fields, map[string]any{
+		"grpc_service": service,
+		"grpc_method":  method,
+		"grpc_type":    kind,
+		"grpc_code":    code.String(),
+		"duration_ms":  float64(time.Since(start).Microseconds()) / 1000,
+	})
+	if err != nil {
+		fields["error"] = loki.ErrorField(err)
+	}
+
+	logger.Log(ctx, cfg.LevelFunc(code), cfg.Message, fields)
+}
+
+// panicFields describes a recovered panic for the call log entry: the panic value, the
+// stack trace starting at the panic site, and the file and line of the panic site, so that
+// they do not point at the interceptor. It must be called from the deferred function.
+func panicFields(r any) map[string]any {
+	stack := utils.GetStackTrace()
+
+	// Skip the frames of the runtime raising the panic
+	for len(stack) > 1 && strings.HasPrefix(stack[0].Function, "runtime.") {
+		stack = stack[1:]
+	}
+
+	fields := map[string]any{
+		"panic":      fmt.Sprint(r),
+		"stacktrace": stack,
+	}
+	if len(stack) > 0 {
+		fields["file"] = filepath.Base(stack[0].File)
+		fields["line"] = stack[0].Line
+	}
+	return fields
+}
+
+// splitMethod splits "/package.Service/Method" into service and method.
+func splitMethod(fullMethod string) (service, method string) {
+	dir, method := path.Split(fullMethod)
+	return path.Clean(dir)[1:], method
+}
+
+// streamType describes a streaming call.
+func streamType(clientStream, serverStream bool) string {
+	switch {
+	case clientStream && serverStream:
+		return "bidi_stream"
+	case clientStream:
+		return "client_stream"
+	case serverStream:
+		return "server_stream"
+	default:
+		return "unary"
+	}
+}
+
+// scopedServerStream overrides the stream context with the request-scoped one.
+type scopedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *scopedServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,242 @@
+package lokigrpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	loki "github.com/edaniel30/loki-logger-go"
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/edaniel30/loki-logger-go/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// capture collects entries through a hook and drops them so nothing is written to stdout.
+type capture struct {
+	mu      sync.Mutex
+	entries []*types.Entry
+}
+
+func (c *capture) Run(ctx context.Context, entry *types.Entry) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+	return false
+}
+
+func (c *capture) get() []*types.Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*types.Entry(nil), c.entries...)
+}
+
+func newTestLogger(t *testing.T) (*loki.Logger, *capture) {
+	t.Helper()
+	c := &capture{}
+	logger, err := loki.New(loki.DefaultConfig(),
+		loki.WithOnlyConsole(true),
+		loki.WithLogLevel(types.LevelDebug),
+		loki.WithHooks(c),
+	)
+	require.NoError(t, err)
+	return logger, c
+}
+
+func incomingContext() context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000},
+	})
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(
+		"x-request-id", "req-1",
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	logger, c := newTestLogger(t)
+	interceptor := UnaryServerInterceptor(logger)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.v1.OrderService/GetOrder"}
+
+	resp, err := interceptor(incomingContext(), "req", info, func(ctx context.Context, req any) (any, error) {
+		reqLogger, ok := loki.FromContext(ctx)
+		require.True(t, ok)
+		reqLogger.Info(ctx, "loading order", nil)
+
+		_, ok = loki.W3CTraceContext(ctx)
+		assert.True(t, ok)
+		return "resp", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "resp", resp)
+
+	entries := c.get()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "req-1", entries[0].Fields["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entries[0].Fields["trace_id"])
+	assert.Equal(t, "10.0.0.1:5000", entries[0].Fields["peer"])
+
+	call := entries[1]
+	assert.Equal(t, "grpc call", call.Message)
+	assert.Equal(t, types.LevelInfo, call.Level)
+	assert.Equal(t, "orders.v1.OrderService", call.Fields["grpc_service"])
+	assert.Equal(t, "GetOrder", call.Fields["grpc_method"])
+	assert.Equal(t, "unary", call.Fields["grpc_type"])
+	assert.Equal(t, "OK", call.Fields["grpc_code"])
+	assert.Contains(t, call.Fields, "duration_ms")
+	assert.Equal(t, "10.0.0.1:5000", call.Fields["peer"])
+}
+
+func TestUnaryServerInterceptor_Error(t *testing.T) {
+	logger, c := newTestLogger(t)
+	interceptor := UnaryServerInterceptor(logger, WithMessage("rpc"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.v1.OrderService/GetOrder"}
+
+	_, err := interceptor(context.Background(), "req", info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.NotFound, "order not found")
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	entries := c.get()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "rpc", entries[0].Message)
+	assert.Equal(t, types.LevelWarn, entries[0].Level)
+	assert.Equal(t, "NotFound", entries[0].Fields["grpc_code"])
+	assert.Contains(t, entries[0].Fields, "error")
+}
+
+func TestUnaryServerInterceptor_Recovery(t *testing.T) {
+	logger, c := newTestLogger(t)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.v1.OrderService/GetOrder"}
+	panicking := func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	}
+
+	_, err := UnaryServerInterceptor(logger)(context.Background(), "req", info, panicking)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	// A single entry describes the call and the panic
+	entries := c.get()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "grpc call", entries[0].Message)
+	assert.Equal(t, "boom", entries[0].Fields["panic"])
+	assert.Equal(t, "Internal", entries[0].Fields["grpc_code"])
+	assert.Equal(t, types.LevelError, entries[0].Level)
+
+	// Caller info points at the panic site, not the interceptor
+	stack, ok := entries[0].Fields["stacktrace"].([]utils.Frame)
+	require.True(t, ok)
+	require.NotEmpty(t, stack)
+	assert.Contains(t, stack[0].Function, "TestUnaryServerInterceptor_Recovery")
+	assert.Equal(t, "interceptors_test.go", entries[0].Fields["file"])
+	assert.Equal(t, stack[0].Line, entries[0].Fields["line"])
+
+	assert.Panics(t, func() {
+		_, _ = UnaryServerInterceptor(logger, WithRecovery(false))(context.Background(), "req", info, panicking)
+	})
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	logger, c := newTestLogger(t)
+	interceptor := StreamServerInterceptor(logger, WithLevelFunc(func(codes.Code) types.Level { return types.LevelDebug }))
+	info := &grpc.StreamServerInfo{FullMethod: "/orders.v1.OrderService/Watch", IsServerStream: true}
+
+	err := interceptor(nil, &fakeServerStream{ctx: incomingContext()}, info, func(srv any, ss grpc.ServerStream) error {
+		_, ok := loki.FromContext(ss.Context())
+		assert.True(t, ok)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = interceptor(nil, &fakeServerStream{ctx: context.Background()}, info, func(srv any, ss grpc.ServerStream) error {
+		panic("stream boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	entries := c.get()
+	require.Len(t, entries, 2)
+	assert.Equal(t, types.LevelDebug, entries[0].Level)
+	assert.Equal(t, "server_stream", entries[0].Fields["grpc_type"])
+	assert.Equal(t, "req-1", entries[0].Fields["request_id"])
+	assert.Equal(t, "stream boom", entries[1].Fields["panic"])
+	assert.Equal(t, "Internal", entries[1].Fields["grpc_code"])
+	assert.Equal(t, "interceptors_test.go", entries[1].Fields["file"])
+}
+
+func TestUnaryServerInterceptor_InvalidRequestID(t *testing.T) {
+	logger, c := newTestLogger(t)
+	interceptor := UnaryServerInterceptor(logger)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.v1.OrderService/GetOrder"}
+	ok := func(ctx context.Context, req any) (any, error) { return nil, nil }
+
+	for _, id := range []string{"req\nforged", strings.Repeat("a", 129)} {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", id))
+		_, err := interceptor(ctx, "req", info, ok)
+		require.NoError(t, err)
+	}
+	_, err := interceptor(context.Background(), "req", info, ok)
+	require.NoError(t, err)
+
+	// Invalid or missing IDs are replaced by generated ones
+	entries := c.get()
+	require.Len(t, entries, 3)
+	for _, entry := range entries {
+		assert.Len(t, entry.Fields["request_id"], 32)
+	}
+}
+
+func TestClientInterceptors(t *testing.T) {
+	logger, c := newTestLogger(t)
+
+	err := UnaryClientInterceptor(logger)(context.Background(), "/orders.v1.OrderService/GetOrder", "req", nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return status.Error(codes.Unavailable, "connection refused")
+		})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	desc := &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}
+	_, err = StreamClientInterceptor(logger)(context.Background(), desc, nil, "/chat.v1.Chat/Talk",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, nil
+		})
+	require.NoError(t, err)
+
+	entries := c.get()
+	require.Len(t, entries, 2)
+	assert.Equal(t, types.LevelError, entries[0].Level)
+	assert.Equal(t, "Unavailable", entries[0].Fields["grpc_code"])
+	assert.Equal(t, "bidi_stream", entries[1].Fields["grpc_type"])
+	assert.Equal(t, "chat.v1.Chat", entries[1].Fields["grpc_service"])
+	assert.Equal(t, "Talk", entries[1].Fields["grpc_method"])
+}
+
+func TestCodeToLevel(t *testing.T) {
+	assert.Equal(t, types.LevelInfo, CodeToLevel(codes.OK))
+	assert.Equal(t, types.LevelWarn, CodeToLevel(codes.InvalidArgument))
+	assert.Equal(t, types.LevelWarn, CodeToLevel(codes.Unauthenticated))
+	assert.Equal(t, types.LevelError, CodeToLevel(codes.Internal))
+	assert.Equal(t, types.LevelError, CodeToLevel(codes.Code(999)))
+}
+
+func TestStreamType(t *testing.T) {
+	assert.Equal(t, "unary", streamType(false, false))
+	assert.Equal(t, "client_stream", streamType(true, false))
+	assert.Equal(t, "server_stream", streamType(false, true))
+	assert.Equal(t, "bidi_stream", streamType(true, true))
+}
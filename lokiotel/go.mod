@@ -0,0 +1,17 @@
+module github.com/edaniel30/loki-logger-go/lokiotel
+
+go 1.25.7
+
+require (
+	github.com/edaniel30/loki-logger-go v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.12.1
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	go.opentelemetry.io/otel v1.46.0 // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
+)
+
+replace github.com/edaniel30/loki-logger-go => ../
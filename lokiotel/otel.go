@@ -0,0 +1,32 @@
+// Package lokiotel adapts OpenTelemetry span contexts to loki-logger-go.
+//
+// It lives in its own module so the core logger stays free of OpenTelemetry dependencies.
+package lokiotel
+
+import (
+	"context"
+
+	loki "github.com/edaniel30/loki-logger-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceContext is a loki.TraceContextExtractor that reads the OpenTelemetry span
+// context from ctx. It returns false when ctx carries no valid span context.
+//
+// Example:
+//
+//	logger, err := loki.New(loki.DefaultConfig(),
+//		loki.WithTraceContextExtractor(lokiotel.TraceContext),
+//	)
+func TraceContext(ctx context.Context) (loki.TraceContext, bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return loki.TraceContext{}, false
+	}
+
+	return loki.TraceContext{
+		TraceID: sc.TraceID().String(),
+		SpanID:  sc.SpanID().String(),
+		Flags:   byte(sc.TraceFlags()),
+	}, true
+}
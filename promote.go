@@ -0,0 +1,47 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edaniel30/loki-logger-go/internal/cardinality"
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// LabelOverflowValue replaces promoted label values beyond Config.MaxLabelValues.
+// Query it to find entries whose label was capped: {tenant="__overflow__"}.
+const LabelOverflowValue = cardinality.OverflowValue
+
+// promoteFields copies the configured fields to labels, capping the number of
+// distinct values per label. Fields are left in place so the log line stays complete.
+func (l *Logger) promoteFields(ctx context.Context, fields map[string]any, labels types.Labels) {
+	for _, key := range l.config.PromotedFields {
+		v, exists := fields[key]
+		if !exists {
+			continue
+		}
+
+		value, ok := v.(string)
+		if !ok {
+			value = fmt.Sprint(v)
+		}
+
+		promoted, overflow, first := l.cardinality.Value(key, value)
+		labels[key] = promoted
+		if !overflow {
+			continue
+		}
+
+		l.metrics.LabelOverflows.Inc()
+		if l.config.OnLabelOverflow != nil {
+			l.config.OnLabelOverflow(key, value)
+		}
+		if first {
+			// Warn once per label; the warning has no promoted fields, so it cannot recurse
+			l.log(ctx, types.LevelWarn, "label cardinality limit reached, new values replaced with "+LabelOverflowValue, map[string]any{
+				"label": key,
+				"limit": l.config.MaxLabelValues,
+			})
+		}
+	}
+}
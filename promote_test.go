@@ -0,0 +1,67 @@
+package loki
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edaniel30/loki-logger-go/internal/cardinality"
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerPromotedFields(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+	logger.config.PromotedFields = []string{"http_status_class", "level"}
+	logger.config.MaxLabelValues = 2
+	logger.cardinality = cardinality.New(2)
+
+	var overflows []string
+	logger.config.OnLabelOverflow = func(label, value string) {
+		overflows = append(overflows, label+"="+value)
+	}
+
+	ctx := context.Background()
+	child := logger.WithLabels(types.Labels{"component": "api"})
+	child.Info(ctx, "request", map[string]any{"http_status_class": "2xx", "level": "custom"})
+	logger.Info(ctx, "request", map[string]any{"http_status_class": 5})
+	logger.Info(ctx, "request", map[string]any{"http_status_class": "4xx"})
+	child.Info(ctx, "request", map[string]any{"http_status_class": "3xx"})
+	logger.Info(ctx, "request", map[string]any{"http_status_class": "2xx"})
+	logger.Info(ctx, "no promoted field", nil)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 7)
+
+	assert.Equal(t, "2xx", entries[0].Labels["http_status_class"])
+	assert.Equal(t, "api", entries[0].Labels["component"])
+	assert.Equal(t, "info", entries[0].Labels["level"]) // system labels cannot be overridden
+	assert.Equal(t, "2xx", entries[0].Fields["http_status_class"])
+
+	assert.Equal(t, "5", entries[1].Labels["http_status_class"])
+
+	// The first overflow logs a warning once, before the entry itself
+	assert.Equal(t, types.LevelWarn, entries[2].Level)
+	assert.Equal(t, "http_status_class", entries[2].Fields["label"])
+	assert.Equal(t, 2, entries[2].Fields["limit"])
+	assert.Equal(t, LabelOverflowValue, entries[3].Labels["http_status_class"])
+	assert.Equal(t, "4xx", entries[3].Fields["http_status_class"])
+
+	// The limit is shared with child loggers, known values keep passing
+	assert.Equal(t, LabelOverflowValue, entries[4].Labels["http_status_class"])
+	assert.Equal(t, "2xx", entries[5].Labels["http_status_class"])
+	assert.NotContains(t, entries[6].Labels, "http_status_class")
+
+	assert.Equal(t, []string{"http_status_class=4xx", "http_status_class=3xx"}, overflows)
+	assert.Equal(t, uint64(2), logger.Stats().LabelOverflows)
+}
+
+func TestNewWithPromotedFields(t *testing.T) {
+	logger, err := New(newTestConfig(), WithPromotedFields("tenant"), WithMaxLabelValues(10))
+	require.NoError(t, err)
+	assert.NotNil(t, logger.cardinality)
+
+	logger, err = New(newTestConfig())
+	require.NoError(t, err)
+	assert.Nil(t, logger.cardinality)
+}
@@ -0,0 +1,61 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/edaniel30/loki-logger-go/utils"
+)
+
+// Recover recovers from a panic in the calling goroutine, logs it with the panic value
+// and a structured stack trace, and synchronously flushes all transports so the entry
+// reaches Loki even if the process is about to die.
+//
+// The panic is logged at LevelError, or at LevelFatal and re-panicked when
+// Config.RepanicOnRecover is enabled. Recover must be deferred directly:
+//
+//	defer logger.Recover(ctx)
+func (l *Logger) Recover(ctx context.Context) {
+	if r := recover(); r != nil {
+		l.handlePanic(ctx, r)
+	}
+}
+
+// Go runs fn in a new goroutine, recovering and logging any panic it raises.
+// See Recover for how panics are reported.
+//
+// Example:
+//
+//	logger.Go(ctx, func(ctx context.Context) {
+//		processJobs(ctx)
+//	})
+func (l *Logger) Go(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		defer l.Recover(ctx)
+		fn(ctx)
+	}()
+}
+
+// handlePanic logs a recovered panic value, flushes, and re-panics if configured to.
+func (l *Logger) handlePanic(ctx context.Context, r any) {
+	fields := map[string]any{
+		"panic":      fmt.Sprint(r),
+		"stacktrace": utils.GetStackTrace(),
+	}
+	if err, ok := r.(error); ok {
+		fields["error"] = ErrorField(err)
+	}
+
+	level := types.LevelError
+	if l.config.RepanicOnRecover {
+		level = types.LevelFatal
+	}
+
+	l.log(ctx, level, "recovered from panic", fields)
+	l.flushBeforeExit()
+
+	if l.config.RepanicOnRecover {
+		panic(r)
+	}
+}
@@ -0,0 +1,87 @@
+package loki
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/internal/mocks"
+	"github.com/edaniel30/loki-logger-go/internal/transport"
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/edaniel30/loki-logger-go/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerRecover(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+
+	assert.NotPanics(t, func() {
+		defer logger.Recover(context.Background())
+		panic(errors.New("nil map write"))
+	})
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, types.LevelError, entries[0].Level)
+	assert.Equal(t, "recovered from panic", entries[0].Message)
+	assert.Equal(t, "nil map write", entries[0].Fields["panic"])
+	assert.Equal(t, "nil map write", entries[0].Fields["error"].(map[string]any)["message"])
+	stack, ok := entries[0].Fields["stacktrace"].([]utils.Frame)
+	require.True(t, ok)
+	assert.NotEmpty(t, stack)
+	assert.Equal(t, 1, mock.FlushCalled)
+
+	// No panic, nothing logged
+	mock.Reset()
+	func() {
+		defer logger.Recover(context.Background())
+	}()
+	assert.Empty(t, mock.GetEntries())
+	assert.Equal(t, 0, mock.FlushCalled)
+}
+
+func TestLoggerRecoverRepanic(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.RepanicOnRecover = true
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	mock := mocks.NewMockTransport("mock")
+	logger.transports = []transport.Transport{mock}
+
+	assert.PanicsWithValue(t, "boom", func() {
+		defer logger.Recover(context.Background())
+		panic("boom")
+	})
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, types.LevelFatal, entries[0].Level)
+	assert.Equal(t, "boom", entries[0].Fields["panic"])
+	assert.NotContains(t, entries[0].Fields, "error")
+	assert.Equal(t, 1, mock.FlushCalled)
+}
+
+func TestLoggerGo(t *testing.T) {
+	logger, mock := newTestLoggerWithMock(t)
+
+	done := make(chan struct{})
+	logger.Go(context.Background(), func(ctx context.Context) {
+		defer close(done)
+		logger.Info(ctx, "working", nil)
+	})
+	<-done
+
+	logger.Go(context.Background(), func(ctx context.Context) {
+		var m map[string]int
+		m["x"] = 1 // panics
+	})
+
+	assert.Eventually(t, func() bool { return len(mock.GetEntries()) == 2 }, time.Second, 5*time.Millisecond)
+	entries := mock.GetEntries()
+	assert.Equal(t, "working", entries[0].Message)
+	assert.Equal(t, "recovered from panic", entries[1].Message)
+	assert.Contains(t, entries[1].Fields["panic"], "assignment to entry in nil map")
+	assert.Contains(t, entries[1].Fields, "error") // runtime errors implement error
+}
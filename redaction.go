@@ -0,0 +1,163 @@
+package loki
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/edaniel30/loki-logger-go/internal/redact"
+)
+
+// RedactStrategy determines how a sensitive value is rewritten.
+type RedactStrategy int
+
+const (
+	// RedactReplace replaces the whole value with "[REDACTED]".
+	RedactReplace RedactStrategy = iota
+	// RedactMask replaces every character with '*' except the last 4 (all of them for values up to 8 characters).
+	RedactMask
+	// RedactHash replaces the value with a truncated SHA-256 hash so equal values can still be correlated.
+	// The hash is unsalted: do not rely on it to protect low-entropy values such as PINs.
+	RedactHash
+)
+
+const (
+	// redactedPlaceholder is the replacement used by RedactReplace.
+	redactedPlaceholder = "[REDACTED]"
+
+	// maskVisibleChars is the number of trailing characters kept visible by RedactMask.
+	maskVisibleChars = 4
+)
+
+// RedactionRule describes which data to redact and how.
+// Redaction is applied to the message and fields of every entry before it reaches any transport.
+type RedactionRule struct {
+	// Keys are field names whose values are always redacted.
+	// Matching is case-insensitive and applies to nested maps at any depth.
+	Keys []string
+
+	// Patterns are matched against the message and every string field value;
+	// only the matching part is redacted.
+	Patterns []*regexp.Regexp
+
+	// Match optionally confirms that a pattern match is sensitive, to tell it apart from
+	// values of the same shape. Matches for which it returns false are left unchanged.
+	// Keys are always redacted.
+	Match func(match string) bool
+
+	// Strategy determines how matched values are rewritten (default: RedactReplace).
+	Strategy RedactStrategy
+}
+
+// RedactCredentials returns a rule that redacts common credential field names
+// such as "password", "token", "authorization" and "api_key".
+func RedactCredentials() RedactionRule {
+	return RedactionRule{
+		Keys: []string{
+			"password", "passwd", "pwd", "secret", "client_secret",
+			"token", "access_token", "refresh_token", "id_token",
+			"api_key", "apikey", "x-api-key", "authorization", "proxy-authorization",
+			"cookie", "set-cookie", "private_key", "session", "session_id",
+		},
+		Strategy: RedactReplace,
+	}
+}
+
+// RedactEmails returns a rule that masks email addresses found in messages and string fields.
+func RedactEmails() RedactionRule {
+	return RedactionRule{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)},
+		Strategy: RedactMask,
+	}
+}
+
+// RedactCardNumbers returns a rule that masks payment card numbers (13 to 19 digits,
+// optionally separated by spaces or dashes) found in messages and string fields.
+// Only numbers passing the Luhn checksum are masked, so that timestamps, snowflake IDs
+// or order numbers of the same length are mostly left alone.
+func RedactCardNumbers() RedactionRule {
+	return RedactionRule{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)},
+		Match:    luhnValid,
+		Strategy: RedactMask,
+	}
+}
+
+// luhnValid reports whether the digits of s, ignoring any other character, pass the
+// Luhn checksum used by payment card numbers.
+func luhnValid(s string) bool {
+	sum, digits := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits > 0 && sum%10 == 0
+}
+
+// RedactBearerTokens returns a rule that redacts "Bearer <token>" credentials found in
+// messages and string fields, e.g. when request headers are logged as a single string.
+func RedactBearerTokens() RedactionRule {
+	return RedactionRule{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`(?i)bearer\s+[a-z0-9\-._~+/]+=*`)},
+		Strategy: RedactReplace,
+	}
+}
+
+// replacer returns the function implementing the strategy.
+func (s RedactStrategy) replacer() func(string) string {
+	switch s {
+	case RedactMask:
+		return maskValue
+	case RedactHash:
+		return hashValue
+	default:
+		return func(string) string { return redactedPlaceholder }
+	}
+}
+
+// maskValue keeps only the last few characters of a value visible.
+func maskValue(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 2*maskVisibleChars {
+		return strings.Repeat("*", len(runes))
+	}
+	visible := len(runes) - maskVisibleChars
+	return strings.Repeat("*", visible) + string(runes[visible:])
+}
+
+// hashValue returns a short, stable SHA-256 digest of a value.
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// newRedactor builds the internal redactor for the configured rules, or nil if there are none.
+func newRedactor(rules []RedactionRule) *redact.Redactor {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	internalRules := make([]redact.Rule, 0, len(rules))
+	for _, rule := range rules {
+		internalRules = append(internalRules, redact.Rule{
+			Keys:     rule.Keys,
+			Patterns: rule.Patterns,
+			Match:    rule.Match,
+			Replace:  rule.Strategy.replacer(),
+		})
+	}
+
+	return redact.New(internalRules...)
+}
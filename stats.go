@@ -0,0 +1,227 @@
+package loki
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/edaniel30/loki-logger-go/internal/metrics"
+	"github.com/edaniel30/loki-logger-go/types"
+)
+
+// Stats is a point-in-time snapshot of the logger's pipeline counters.
+// Counters are cumulative since the logger was created and shared by child loggers.
+type Stats struct {
+	// Logged counts entries accepted by the pipeline, keyed by level name
+	Logged map[string]uint64
+
+	// HookDropped counts entries dropped by a hook
+	HookDropped uint64
+
+	// RateLimited counts entries dropped or downgraded by the rate limiter
+	RateLimited uint64
+
+	// Deduplicated counts entries collapsed into a deduplication summary
+	Deduplicated uint64
+
+	// LabelOverflows counts promoted label values replaced with LabelOverflowValue
+	LabelOverflows uint64
+
+	// OutOfWindow counts entries logged with LogAt or Event.Time dropped because their timestamp
+	// is outside the window Loki accepts
+	OutOfWindow uint64
+
+	// Transports holds the counters of each transport, keyed by transport name
+	Transports map[string]TransportStats
+}
+
+// TransportStats holds the counters of a single transport.
+// Batch, push and retry counters are only populated for the Loki transport.
+type TransportStats struct {
+	Written     uint64 // entries handed to the transport
+	WriteErrors uint64 // writes that returned an error
+	Batches     uint64 // batches flushed to Loki
+	Pushed      uint64 // entries successfully pushed to Loki
+	Retries     uint64 // retried push attempts
+	PushErrors  uint64 // batches that failed after all retries
+	Failed      uint64 // entries lost because their batch failed
+	Rejected    uint64 // entries Loki refused, isolated from their batch
+	PushLatency HistogramStats
+}
+
+// HistogramStats is a snapshot of a latency histogram in seconds.
+type HistogramStats struct {
+	Buckets []Bucket // cumulative counts per upper bound
+	Sum     float64  // sum of all observations in seconds
+	Count   uint64   // number of observations
+}
+
+// Bucket is a cumulative histogram bucket.
+type Bucket struct {
+	UpperBound float64 // upper bound in seconds
+	Count      uint64  // observations less than or equal to UpperBound
+}
+
+// Stats returns a snapshot of the logger's pipeline counters.
+//
+// Example:
+//
+//	stats := logger.Stats()
+//	fmt.Println(stats.Transports["loki"].Failed)
+func (l *Logger) Stats() Stats {
+	stats := Stats{
+		Logged:         make(map[string]uint64),
+		HookDropped:    l.metrics.HookDropped.Load(),
+		RateLimited:    l.metrics.RateLimited.Load(),
+		Deduplicated:   l.metrics.Deduplicated.Load(),
+		LabelOverflows: l.metrics.LabelOverflows.Load(),
+		OutOfWindow:    l.metrics.OutOfWindow.Load(),
+		Transports:     make(map[string]TransportStats),
+	}
+
+	for level := types.LevelDebug; level <= types.LevelPanic; level++ {
+		stats.Logged[level.String()] = l.metrics.LoggedCount(level)
+	}
+
+	for _, name := range l.metrics.TransportNames() {
+		stats.Transports[name] = transportStats(l.metrics.Transport(name))
+	}
+
+	return stats
+}
+
+func transportStats(m *metrics.Transport) TransportStats {
+	bounds, cumulative, sum, count := m.PushLatency.Snapshot()
+
+	buckets := make([]Bucket, len(bounds))
+	for i, bound := range bounds {
+		buckets[i] = Bucket{UpperBound: bound, Count: cumulative[i]}
+	}
+
+	return TransportStats{
+		Written:     m.Written.Load(),
+		WriteErrors: m.WriteErrors.Load(),
+		Batches:     m.Batches.Load(),
+		Pushed:      m.Pushed.Load(),
+		Retries:     m.Retries.Load(),
+		PushErrors:  m.PushErrors.Load(),
+		Failed:      m.Failed.Load(),
+		Rejected:    m.Rejected.Load(),
+		PushLatency: HistogramStats{Buckets: buckets, Sum: sum, Count: count},
+	}
+}
+
+// MetricsHandler returns an http.Handler exposing the logger's Stats
+// in the Prometheus text exposition format, without depending on a Prometheus client library.
+//
+// Example:
+//
+//	http.Handle("/metrics/logger", logger.MetricsHandler())
+func (l *Logger) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = l.Stats().WritePrometheus(w)
+	})
+}
+
+// WritePrometheus writes the stats in the Prometheus text exposition format.
+// Metric names are prefixed with "loki_logger_".
+func (s Stats) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+
+	levels := make([]string, 0, len(s.Logged))
+	for level := range s.Logged {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+
+	writeHeader(&b, "loki_logger_entries_total", "counter", "Entries accepted by the logging pipeline.")
+	for _, level := range levels {
+		writeSample(&b, "loki_logger_entries_total", s.Logged[level], "level", level)
+	}
+
+	writeHeader(&b, "loki_logger_entries_dropped_total", "counter", "Entries dropped or downgraded before reaching the transports.")
+	writeSample(&b, "loki_logger_entries_dropped_total", s.Deduplicated, "reason", "dedupe")
+	writeSample(&b, "loki_logger_entries_dropped_total", s.HookDropped, "reason", "hook")
+	writeSample(&b, "loki_logger_entries_dropped_total", s.OutOfWindow, "reason", "out_of_window")
+	writeSample(&b, "loki_logger_entries_dropped_total", s.RateLimited, "reason", "rate_limit")
+
+	writeHeader(&b, "loki_logger_label_overflows_total", "counter", "Promoted label values replaced because of the cardinality limit.")
+	writeSample(&b, "loki_logger_label_overflows_total", s.LabelOverflows)
+
+	names := make([]string, 0, len(s.Transports))
+	for name := range s.Transports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(TransportStats) uint64
+	}{
+		{"loki_logger_transport_writes_total", "Entries handed to the transport.", func(t TransportStats) uint64 { return t.Written }},
+		{"loki_logger_transport_write_errors_total", "Transport writes that returned an error.", func(t TransportStats) uint64 { return t.WriteErrors }},
+		{"loki_logger_transport_batches_total", "Batches flushed by the transport.", func(t TransportStats) uint64 { return t.Batches }},
+		{"loki_logger_transport_pushed_total", "Entries successfully pushed by the transport.", func(t TransportStats) uint64 { return t.Pushed }},
+		{"loki_logger_transport_retries_total", "Retried push attempts.", func(t TransportStats) uint64 { return t.Retries }},
+		{"loki_logger_transport_push_errors_total", "Batches that failed after all retries.", func(t TransportStats) uint64 { return t.PushErrors }},
+		{"loki_logger_transport_failed_total", "Entries lost because their batch failed.", func(t TransportStats) uint64 { return t.Failed }},
+		{"loki_logger_transport_rejected_total", "Entries refused by Loki and isolated from their batch.", func(t TransportStats) uint64 { return t.Rejected }},
+	}
+
+	for _, c := range counters {
+		writeHeader(&b, c.name, "counter", c.help)
+		for _, name := range names {
+			writeSample(&b, c.name, c.value(s.Transports[name]), "transport", name)
+		}
+	}
+
+	const latency = "loki_logger_transport_push_duration_seconds"
+	writeHeader(&b, latency, "histogram", "Latency of push attempts in seconds.")
+	for _, name := range names {
+		h := s.Transports[name].PushLatency
+		for _, bucket := range h.Buckets {
+			writeSample(&b, latency+"_bucket", bucket.Count, "transport", name, "le", formatFloat(bucket.UpperBound))
+		}
+		writeSample(&b, latency+"_bucket", h.Count, "transport", name, "le", "+Inf")
+		fmt.Fprintf(&b, "%s_sum{transport=%q} %s\n", latency, name, formatFloat(h.Sum))
+		writeSample(&b, latency+"_count", h.Count, "transport", name)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeSample writes a single sample; labels are given as alternating names and values.
+func writeSample(b *strings.Builder, name string, value uint64, labels ...string) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=%q", labels[i], labels[i+1])
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatUint(value, 10))
+	b.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,98 @@
+package loki
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edaniel30/loki-logger-go/internal/mocks"
+	"github.com/edaniel30/loki-logger-go/internal/transport"
+	"github.com/edaniel30/loki-logger-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerStats(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.DedupeWindow = time.Minute
+	cfg.Hooks = []Hook{HookFunc(func(ctx context.Context, entry *types.Entry) bool {
+		return entry.Message != "drop me"
+	})}
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	defer func() { _ = logger.Close() }()
+
+	mock := mocks.NewMockTransport("mock")
+	failing := mocks.NewMockTransport("failing")
+	failing.WriteErr = errors.New("write failed")
+	logger.transports = []transport.Transport{mock, failing}
+
+	ctx := context.Background()
+	logger.Info(ctx, "hello", nil)
+	logger.Info(ctx, "hello", nil) // collapsed by the deduper
+	logger.WithLabels(types.Labels{"component": "db"}).Warn(ctx, "slow", nil)
+	logger.Info(ctx, "drop me", nil)
+
+	stats := logger.Stats()
+	assert.Equal(t, uint64(1), stats.Logged["info"])
+	assert.Equal(t, uint64(1), stats.Logged["warn"])
+	assert.Equal(t, uint64(0), stats.Logged["error"])
+	assert.Equal(t, uint64(1), stats.Deduplicated)
+	assert.Equal(t, uint64(1), stats.HookDropped)
+	assert.Equal(t, uint64(0), stats.RateLimited)
+	assert.Equal(t, uint64(2), stats.Transports["mock"].Written)
+	assert.Equal(t, uint64(0), stats.Transports["mock"].WriteErrors)
+	assert.Equal(t, uint64(2), stats.Transports["failing"].WriteErrors)
+}
+
+func TestLoggerStats_Loki(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig()
+	cfg.OnlyConsole = false
+	cfg.LokiHost = server.URL
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	defer func() { _ = logger.Close() }()
+
+	logger.transports = logger.transports[1:] // keep only loki to avoid console output
+
+	logger.Info(context.Background(), "pushed", nil)
+	require.NoError(t, logger.Flush(context.Background()))
+
+	loki := logger.Stats().Transports["loki"]
+	assert.Equal(t, uint64(1), loki.Written)
+	assert.Equal(t, uint64(1), loki.Batches)
+	assert.Equal(t, uint64(1), loki.Pushed)
+	assert.Equal(t, uint64(1), loki.PushLatency.Count)
+	assert.Len(t, loki.PushLatency.Buckets, 11)
+}
+
+func TestLoggerMetricsHandler(t *testing.T) {
+	logger, _ := newTestLoggerWithMock(t)
+	logger.Error(context.Background(), "boom", nil)
+
+	rec := httptest.NewRecorder()
+	logger.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE loki_logger_entries_total counter\n")
+	assert.Contains(t, body, `loki_logger_entries_total{level="error"} 1`)
+	assert.Contains(t, body, `loki_logger_entries_dropped_total{reason="rate_limit"} 0`)
+	assert.Contains(t, body, `loki_logger_entries_dropped_total{reason="out_of_window"} 0`)
+	assert.Contains(t, body, "loki_logger_label_overflows_total 0\n")
+	assert.Contains(t, body, `loki_logger_transport_writes_total{transport="mock"} 1`)
+	assert.Contains(t, body, "# TYPE loki_logger_transport_push_duration_seconds histogram\n")
+	assert.Contains(t, body, `loki_logger_transport_push_duration_seconds_bucket{transport="mock",le="0.005"} 0`)
+	assert.Contains(t, body, `loki_logger_transport_push_duration_seconds_bucket{transport="mock",le="+Inf"} 0`)
+	assert.Contains(t, body, `loki_logger_transport_push_duration_seconds_count{transport="mock"} 0`)
+}
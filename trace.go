@@ -0,0 +1,115 @@
+package loki
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TraceContext identifies the trace and span an entry was logged in.
+// The IDs are lowercase hex strings as defined by W3C Trace Context.
+type TraceContext struct {
+	TraceID string // 32 hex characters
+	SpanID  string // 16 hex characters
+	Flags   byte   // Trace flags; bit 0 is "sampled"
+}
+
+// Sampled reports whether the sampled flag is set.
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&0x01 == 0x01
+}
+
+// TraceContextExtractor extracts trace correlation data from a context.
+// Return false if the context carries no trace.
+type TraceContextExtractor func(ctx context.Context) (TraceContext, bool)
+
+// traceContextKey is the context key used to store a TraceContext.
+type traceContextKey struct{}
+
+// ParseTraceParent parses a W3C "traceparent" header value
+// ("version-traceid-parentid-flags", e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01").
+// Invalid headers, including all-zero IDs and the forbidden version "ff", return false.
+func ParseTraceParent(header string) (TraceContext, bool) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(header)), "-")
+	if len(parts) < 4 {
+		return TraceContext{}, false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	// Version 00 has exactly four parts; future versions may append more
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return TraceContext{}, false
+	}
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return TraceContext{}, false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return TraceContext{}, false
+	}
+
+	flagBytes, _ := hex.DecodeString(flags) // validated above
+
+	return TraceContext{TraceID: traceID, SpanID: spanID, Flags: flagBytes[0]}, true
+}
+
+// ContextWithTraceContext returns a copy of ctx carrying tc, to be read by W3CTraceContext.
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// ContextWithTraceParent parses a W3C "traceparent" header value and stores the result in ctx.
+// If the header is invalid, ctx is returned unchanged.
+//
+// Example:
+//
+//	ctx := loki.ContextWithTraceParent(r.Context(), r.Header.Get("traceparent"))
+func ContextWithTraceParent(ctx context.Context, header string) context.Context {
+	if tc, ok := ParseTraceParent(header); ok {
+		return ContextWithTraceContext(ctx, tc)
+	}
+	return ctx
+}
+
+// W3CTraceContext is a TraceContextExtractor that reads the trace context stored by
+// ContextWithTraceParent or ContextWithTraceContext (the httplog middleware does this
+// automatically when traceparent extraction is enabled).
+//
+// Example:
+//
+//	loki.WithTraceContextExtractor(loki.W3CTraceContext)
+func W3CTraceContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// addTraceFields adds trace_id, span_id and trace_flags from the extracted trace context
+// without overwriting fields provided by the caller.
+func addTraceFields(fields map[string]any, tc TraceContext) {
+	values := map[string]string{
+		"trace_id":    tc.TraceID,
+		"span_id":     tc.SpanID,
+		"trace_flags": fmt.Sprintf("%02x", tc.Flags),
+	}
+	for k, v := range values {
+		if v == "" {
+			continue
+		}
+		if _, exists := fields[k]; !exists {
+			fields[k] = v
+		}
+	}
+}
+
+// isHex reports whether s is exactly n lowercase hex characters.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
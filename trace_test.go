@@ -0,0 +1,81 @@
+package loki
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edaniel30/loki-logger-go/internal/mocks"
+	"github.com/edaniel30/loki-logger-go/internal/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	tc, ok := ParseTraceParent(testTraceParent)
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", tc.SpanID)
+	assert.Equal(t, byte(0x01), tc.Flags)
+	assert.True(t, tc.Sampled())
+
+	tc, ok = ParseTraceParent(" 00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-00 ")
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	assert.False(t, tc.Sampled())
+
+	// Future versions may carry extra parts
+	_, ok = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.True(t, ok)
+
+	for _, header := range []string{
+		"",
+		"garbage",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f35-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+	} {
+		_, ok := ParseTraceParent(header)
+		assert.False(t, ok, header)
+	}
+}
+
+func TestContextWithTraceParent(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := W3CTraceContext(ctx)
+	assert.False(t, ok)
+
+	assert.Equal(t, ctx, ContextWithTraceParent(ctx, "invalid"))
+
+	tc, ok := W3CTraceContext(ContextWithTraceParent(ctx, testTraceParent))
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+}
+
+func TestLoggerTraceContextExtractor(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.TraceContextExtractor = W3CTraceContext
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	mock := mocks.NewMockTransport("mock")
+	logger.transports = []transport.Transport{mock}
+
+	ctx := ContextWithTraceParent(context.Background(), testTraceParent)
+	logger.Info(ctx, "traced", nil)
+	logger.Info(ctx, "caller wins", map[string]any{"span_id": "custom"})
+	logger.Info(context.Background(), "untraced", nil)
+
+	entries := mock.GetEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entries[0].Fields["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", entries[0].Fields["span_id"])
+	assert.Equal(t, "01", entries[0].Fields["trace_flags"])
+	assert.Equal(t, "custom", entries[1].Fields["span_id"])
+	assert.NotContains(t, entries[2].Fields, "trace_id")
+}
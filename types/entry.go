@@ -1,10 +1,39 @@
 package types
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+)
 
 // Labels is a map of key-value pairs for indexing in Loki.
 type Labels map[string]string
 
+// Key returns a deterministic string identifying this label set.
+// Keys are sorted alphabetically so that equal label sets always produce the same key,
+// which makes it suitable for grouping entries by Loki stream.
+func (l Labels) Key() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(l[k])
+		b.WriteString(";")
+	}
+
+	return b.String()
+}
+
 // Entry represents a single log record with all its associated data.
 // This is the internal representation of a log entry before it's sent to transports.
 type Entry struct {
@@ -22,4 +51,9 @@ type Entry struct {
 
 	// Labels are key-value pairs used for indexing in Loki
 	Labels Labels
+
+	// Metadata is sent to Loki as structured metadata: attached to the line
+	// without being indexed or part of the JSON body. It suits high-cardinality
+	// values such as trace or user IDs. Requires Loki 3.x.
+	Metadata map[string]string
 }
@@ -0,0 +1,18 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelsKey(t *testing.T) {
+	assert.Equal(t, "", Labels{}.Key())
+	assert.Equal(t, "", Labels(nil).Key())
+	assert.Equal(t, "env=prod;", Labels{"env": "prod"}.Key())
+	assert.Equal(t, "app=myapp;env=prod;region=us-east;", Labels{
+		"region": "us-east",
+		"env":    "prod",
+		"app":    "myapp",
+	}.Key())
+}
@@ -6,7 +6,9 @@ import (
 )
 
 // Level represents the severity of a log entry.
-// Levels are ordered from least to most severe.
+// Levels are ordered from least to most severe, except LevelPanic: it was added after
+// LevelFatal to keep the existing values stable, and ranks between LevelError and
+// LevelFatal. Compare levels with IsEnabled rather than as integers.
 type Level int
 
 const (
@@ -20,6 +22,9 @@ const (
 	LevelError
 	// LevelFatal represents severe errors that lead to application termination.
 	LevelFatal
+	// LevelPanic represents errors after which the logger panics once the entry is flushed.
+	// It is more severe than LevelError and less severe than LevelFatal.
+	LevelPanic
 )
 
 // String returns the string representation of the Level.
@@ -33,6 +38,8 @@ func (l Level) String() string {
 		return "warn"
 	case LevelError:
 		return "error"
+	case LevelPanic:
+		return "panic"
 	case LevelFatal:
 		return "fatal"
 	default:
@@ -42,7 +49,7 @@ func (l Level) String() string {
 
 // ParseLevel converts a string to its corresponding Level.
 // Returns an error if the string is not a valid level.
-// Valid values: "debug", "info", "warn", "warning", "error", "fatal" (case-insensitive)
+// Valid values: "debug", "info", "warn", "warning", "error", "panic", "fatal" (case-insensitive)
 func ParseLevel(level string) (Level, error) {
 	switch strings.ToLower(level) {
 	case "debug":
@@ -53,6 +60,8 @@ func ParseLevel(level string) (Level, error) {
 		return LevelWarn, nil
 	case "error":
 		return LevelError, nil
+	case "panic":
+		return LevelPanic, nil
 	case "fatal":
 		return LevelFatal, nil
 	default:
@@ -63,7 +72,19 @@ func ParseLevel(level string) (Level, error) {
 // IsEnabled checks whether this level should be logged given the configured minimum level.
 // Returns true if the level is equal to or more severe than the configured level.
 func (l Level) IsEnabled(configuredLevel Level) bool {
-	return l >= configuredLevel
+	return l.severity() >= configuredLevel.severity()
+}
+
+// severity returns the rank of the level from least to most severe.
+func (l Level) severity() int {
+	switch l {
+	case LevelPanic:
+		return int(LevelError) + 1
+	case LevelFatal:
+		return int(LevelError) + 2
+	default:
+		return int(l)
+	}
 }
 
 // MarshalText implements encoding.TextMarshaler for JSON/YAML serialization.
@@ -52,7 +52,7 @@ func TestLevelJSONSerialization(t *testing.T) {
 			Level Level `json:"level"`
 		}
 
-		levels := []Level{LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal}
+		levels := []Level{LevelDebug, LevelInfo, LevelWarn, LevelError, LevelPanic, LevelFatal}
 
 		for _, originalLevel := range levels {
 			data := testStruct{Level: originalLevel}
@@ -77,5 +77,16 @@ func TestLevelIsEnabled(t *testing.T) {
 	assert.True(t, LevelInfo.IsEnabled(LevelDebug))
 	assert.True(t, LevelWarn.IsEnabled(LevelDebug))
 	assert.True(t, LevelError.IsEnabled(LevelDebug))
+	assert.True(t, LevelPanic.IsEnabled(LevelDebug))
 	assert.True(t, LevelFatal.IsEnabled(LevelDebug))
+	assert.True(t, LevelFatal.IsEnabled(LevelPanic))
+	assert.False(t, LevelError.IsEnabled(LevelPanic))
+	assert.False(t, LevelPanic.IsEnabled(LevelFatal))
+	assert.True(t, LevelPanic.IsEnabled(LevelError))
+}
+
+func TestLevelValues(t *testing.T) {
+	// Existing values are stable; LevelPanic was added after LevelFatal
+	assert.Equal(t, Level(4), LevelFatal)
+	assert.Equal(t, Level(5), LevelPanic)
 }
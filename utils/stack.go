@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"runtime"
 )
 
@@ -55,3 +56,49 @@ func containsPackage(funcName, pkgName string) bool {
 	// We check if the function name starts with our package path
 	return len(funcName) >= len(pkgName) && funcName[:len(pkgName)] == pkgName
 }
+
+// maxStackDepth is the maximum number of frames captured by GetStackTrace.
+const maxStackDepth = 32
+
+// Frame is a single frame of a captured stack trace.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// String returns the frame as "function (file:line)".
+func (f Frame) String() string {
+	return fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line)
+}
+
+// GetStackTrace captures the current call stack as structured frames.
+// Frames belonging to the logger package are trimmed, so the trace starts at
+// the code that called the logger. At most maxStackDepth frames are returned.
+func GetStackTrace() []Frame {
+	pcs := make([]uintptr, maxStackDepth+16) // extra room for the trimmed logger frames
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	stack := make([]Frame, 0, maxStackDepth)
+	seenLoggerFrame := false
+	for {
+		frame, more := frames.Next()
+
+		if len(stack) == 0 && containsPackage(frame.Function, LoggerPackageName) {
+			seenLoggerFrame = true
+		} else if seenLoggerFrame {
+			stack = append(stack, Frame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+			})
+		}
+
+		if !more || len(stack) == maxStackDepth {
+			break
+		}
+	}
+
+	return stack
+}